@@ -1,6 +1,10 @@
 package audio
 
 import (
+	"encoding/binary"
+	"io"
+	"time"
+
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/memory"
 )
@@ -30,7 +34,7 @@ type APU struct {
 	control        Control
 	audioer        Audioer
 	samplesCounter int
-	throttle       int
+	stems          *Stems
 }
 
 // NewAPU creates APU instance
@@ -77,12 +81,30 @@ func NewAPU(core *cpu.Core, mmu *memory.MMU, audioer Audioer) (*APU, error) {
 		return nil, err
 	}
 
-	// register to the cpu's clock
-	core.RegisterToClockChanges(&a)
+	// register to the cpu's clock (not affected by double speed)
+	core.RegisterToFixedClockChanges(&a)
 
 	return &a, nil
 }
 
+// Drift returns how far the queued samples are ahead of a full
+// buffer (negative when there are less samples queued)
+func (a *APU) Drift() time.Duration {
+
+	// the queue size is in bytes of interleaved stereo 8 bit samples,
+	// 2 bytes per frame (a left and a right sample), the buffer size
+	// is in frames
+	frames := int64(a.audioer.SamplesCount())/2 - int64(a.audioer.BufferSize())
+
+	return time.Duration(frames) * time.Second / time.Duration(a.audioer.Frequency())
+}
+
+// SetStems sets the per channel recording (nil to stop), the stems
+// are recorded at the samples rate
+func (a *APU) SetStems(stems *Stems) {
+	a.stems = stems
+}
+
 // ClockChanged is called after every instruction execution
 func (a *APU) ClockChanged(cycles int) error {
 
@@ -108,21 +130,25 @@ func (a *APU) ClockChanged(cycles int) error {
 
 	a.samplesCounter += cycles
 
-	sc := a.audioer.SamplesCount()
-	bs := a.audioer.BufferSize()
-
-	if sc < uint32((3*bs)/4) && a.throttle > -10 {
-		a.throttle--
-	} else if sc == uint32(bs) && a.throttle < -5 {
-		a.throttle++
-	}
-
-	freq := (cpu.Frequency / a.audioer.Frequency()) + a.throttle
+	freq := cpu.Frequency / a.audioer.Frequency()
 
 	for a.samplesCounter >= freq {
 
 		a.samplesCounter = a.samplesCounter - freq
 
+		if a.stems != nil {
+
+			var levels [4]byte
+
+			if a.control.soundOn() {
+				levels = [4]byte{a.ch1.output(), a.ch2.output(), a.ch3.output(), a.ch4.output()}
+			}
+
+			if err := a.stems.write(levels); err != nil {
+				return err
+			}
+		}
+
 		var sampleLeft byte
 		var sampleRight byte
 
@@ -186,3 +212,71 @@ func (a *APU) ClockChanged(cycles int) error {
 
 	return nil
 }
+
+// SaveState writes the channels, control unit and frame sequencer to 'w'
+func (a *APU) SaveState(w io.Writer) error {
+
+	if err := a.ch1.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := a.ch2.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := a.ch3.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := a.ch4.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := a.control.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := a.fs.SaveState(w); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, int32(a.samplesCounter))
+}
+
+// LoadState reads the channels, control unit and frame sequencer from 'r'
+func (a *APU) LoadState(r io.Reader) error {
+
+	if err := a.ch1.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := a.ch2.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := a.ch3.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := a.ch4.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := a.control.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := a.fs.LoadState(r); err != nil {
+		return err
+	}
+
+	var counter int32
+
+	if err := binary.Read(r, binary.LittleEndian, &counter); err != nil {
+		return err
+	}
+
+	a.samplesCounter = int(counter)
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package audio
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
 
 const (
 	channel1 = 0x01
@@ -18,6 +23,13 @@ const AddrNR51 uint16 = 0xFF25
 // AddrNR52 is the NR52 register address
 const AddrNR52 uint16 = 0xFF26
 
+// controlState is the serialized form of the control unit
+type controlState struct {
+	NR50 byte
+	NR51 byte
+	NR52 byte
+}
+
 // Control and status manager
 type Control struct {
 	nr50 byte
@@ -92,3 +104,30 @@ func (c *Control) leftChannels() byte {
 func (c *Control) rightChannels() byte {
 	return c.nr51 & 0x0F
 }
+
+// SaveState writes the control unit registers to 'w'
+func (c *Control) SaveState(w io.Writer) error {
+
+	st := controlState{
+		NR50: c.nr50,
+		NR51: c.nr51,
+		NR52: c.nr52}
+
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// LoadState reads the control unit registers from 'r'
+func (c *Control) LoadState(r io.Reader) error {
+
+	var st controlState
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	c.nr50 = st.NR50
+	c.nr51 = st.NR51
+	c.nr52 = st.NR52
+
+	return nil
+}
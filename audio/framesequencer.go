@@ -1,5 +1,10 @@
 package audio
 
+import (
+	"encoding/binary"
+	"io"
+)
+
 // LengthRate is the length period in cpu cycles
 const LengthRate int = 16384
 
@@ -9,6 +14,13 @@ const EnvelopeRate int = 65536
 // SweepeRate is the sweep period in cpu cycles
 const SweepeRate int = 32768
 
+// frameSequencerState is the serialized form of the frame sequencer
+type frameSequencerState struct {
+	SweepCounter    int32
+	EnvelopeCounter int32
+	LengthCounter   int32
+}
+
 // FrameSequencer generates low frequency clocks for the
 // modulation units. It is clocked by a 512 Hz timer.
 type FrameSequencer struct {
@@ -58,3 +70,30 @@ func (f *FrameSequencer) ClockChanged(cycles int) error {
 
 	return nil
 }
+
+// SaveState writes the frame sequencer counters to 'w'
+func (f *FrameSequencer) SaveState(w io.Writer) error {
+
+	st := frameSequencerState{
+		SweepCounter:    int32(f.sweepCounter),
+		EnvelopeCounter: int32(f.envelopeCounter),
+		LengthCounter:   int32(f.lengthCounter)}
+
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// LoadState reads the frame sequencer counters from 'r'
+func (f *FrameSequencer) LoadState(r io.Reader) error {
+
+	var st frameSequencerState
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	f.sweepCounter = int(st.SweepCounter)
+	f.envelopeCounter = int(st.EnvelopeCounter)
+	f.lengthCounter = int(st.LengthCounter)
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package audio
 
 import (
+	"encoding/binary"
+	"io"
+
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/memory"
 )
@@ -17,6 +20,19 @@ const AddrNR43 uint16 = 0xFF22
 // AddrNR44 is the NR44 register address
 const AddrNR44 uint16 = 0xFF23
 
+// noiseState is the serialized form of the sound channel 4
+type noiseState struct {
+	NR41             byte
+	NR42             byte
+	NR43             byte
+	NR44             byte
+	FrequencyCounter int32
+	EnvalopeCounter  byte
+	Volume           byte
+	WaveState        bool
+	LFSR             uint16
+}
+
 // Noise sound channel
 type Noise struct {
 	nr41 byte
@@ -235,3 +251,42 @@ func (n *Noise) clockShift() byte {
 func (n *Noise) dac() bool {
 	return n.nr42&0xF8 != 0
 }
+
+// SaveState writes the sound channel 4 registers and counters to 'w'
+func (n *Noise) SaveState(w io.Writer) error {
+
+	st := noiseState{
+		NR41:             n.nr41,
+		NR42:             n.nr42,
+		NR43:             n.nr43,
+		NR44:             n.nr44,
+		FrequencyCounter: int32(n.frequencyCounter),
+		EnvalopeCounter:  n.envalopeCounter,
+		Volume:           n.volume,
+		WaveState:        n.waveState,
+		LFSR:             n.lfsr}
+
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// LoadState reads the sound channel 4 registers and counters from 'r'
+func (n *Noise) LoadState(r io.Reader) error {
+
+	var st noiseState
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	n.nr41 = st.NR41
+	n.nr42 = st.NR42
+	n.nr43 = st.NR43
+	n.nr44 = st.NR44
+	n.frequencyCounter = int(st.FrequencyCounter)
+	n.envalopeCounter = st.EnvalopeCounter
+	n.volume = st.Volume
+	n.waveState = st.WaveState
+	n.lfsr = st.LFSR
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package audio
+
+// NullAudioer is an Audioer that drops all samples
+type NullAudioer struct {
+}
+
+// Queue drops the samples
+func (n *NullAudioer) Queue(samples []byte) error {
+	return nil
+}
+
+// Frequency of sound (samples/sec)
+func (n *NullAudioer) Frequency() int {
+	return 44100
+}
+
+// BufferSize is the size of the samples buffer
+func (n *NullAudioer) BufferSize() uint16 {
+	return 512
+}
+
+// SamplesCount always reports a full buffer (in bytes,
+// 2 per stereo frame)
+func (n *NullAudioer) SamplesCount() uint32 {
+	return 2 * uint32(n.BufferSize())
+}
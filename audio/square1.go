@@ -1,6 +1,11 @@
 package audio
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
 
 // AddrNR10 is the NR10 register address
 const AddrNR10 uint16 = 0xFF10
@@ -17,6 +22,22 @@ const AddrNR13 uint16 = 0xFF13
 // AddrNR14 is the NR14 register address
 const AddrNR14 uint16 = 0xFF14
 
+// square1State is the serialized form of the sound channel 1
+type square1State struct {
+	NR10             byte
+	NR11             byte
+	NR12             byte
+	NR13             byte
+	NR14             byte
+	FrequencyCounter int32
+	EnvalopeCounter  byte
+	SweepCounter     byte
+	Volume           byte
+	WavePos          byte
+	WaveState        bool
+	FreqShadow       uint16
+}
+
 // Square1 sound channel
 type Square1 struct {
 	nr10 byte
@@ -277,3 +298,48 @@ func (s *Square1) setFrequency(frequency uint16) {
 func (s *Square1) dac() bool {
 	return s.nr12&0xF8 != 0
 }
+
+// SaveState writes the sound channel 1 registers and counters to 'w'
+func (s *Square1) SaveState(w io.Writer) error {
+
+	st := square1State{
+		NR10:             s.nr10,
+		NR11:             s.nr11,
+		NR12:             s.nr12,
+		NR13:             s.nr13,
+		NR14:             s.nr14,
+		FrequencyCounter: int32(s.frequencyCounter),
+		EnvalopeCounter:  s.envalopeCounter,
+		SweepCounter:     s.sweepCounter,
+		Volume:           s.volume,
+		WavePos:          s.wavePos,
+		WaveState:        s.waveState,
+		FreqShadow:       s.freqShadow}
+
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// LoadState reads the sound channel 1 registers and counters from 'r'
+func (s *Square1) LoadState(r io.Reader) error {
+
+	var st square1State
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	s.nr10 = st.NR10
+	s.nr11 = st.NR11
+	s.nr12 = st.NR12
+	s.nr13 = st.NR13
+	s.nr14 = st.NR14
+	s.frequencyCounter = int(st.FrequencyCounter)
+	s.envalopeCounter = st.EnvalopeCounter
+	s.sweepCounter = st.SweepCounter
+	s.volume = st.Volume
+	s.wavePos = st.WavePos
+	s.waveState = st.WaveState
+	s.freqShadow = st.FreqShadow
+
+	return nil
+}
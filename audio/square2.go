@@ -1,6 +1,11 @@
 package audio
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
 
 // AddrNR21 is the NR21 register address
 const AddrNR21 uint16 = 0xFF16
@@ -14,6 +19,19 @@ const AddrNR23 uint16 = 0xFF18
 // AddrNR24 is the NR24 register address
 const AddrNR24 uint16 = 0xFF19
 
+// square2State is the serialized form of the sound channel 2
+type square2State struct {
+	NR21             byte
+	NR22             byte
+	NR23             byte
+	NR24             byte
+	FrequencyCounter int32
+	EnvalopeCounter  byte
+	Volume           byte
+	WavePos          byte
+	WaveState        bool
+}
+
 // Square2 sound channel
 type Square2 struct {
 	nr21 byte
@@ -205,3 +223,42 @@ func (s *Square2) frequency() uint16 {
 func (s *Square2) dac() bool {
 	return s.nr22&0xF8 != 0
 }
+
+// SaveState writes the sound channel 2 registers and counters to 'w'
+func (s *Square2) SaveState(w io.Writer) error {
+
+	st := square2State{
+		NR21:             s.nr21,
+		NR22:             s.nr22,
+		NR23:             s.nr23,
+		NR24:             s.nr24,
+		FrequencyCounter: int32(s.frequencyCounter),
+		EnvalopeCounter:  s.envalopeCounter,
+		Volume:           s.volume,
+		WavePos:          s.wavePos,
+		WaveState:        s.waveState}
+
+	return binary.Write(w, binary.LittleEndian, &st)
+}
+
+// LoadState reads the sound channel 2 registers and counters from 'r'
+func (s *Square2) LoadState(r io.Reader) error {
+
+	var st square2State
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	s.nr21 = st.NR21
+	s.nr22 = st.NR22
+	s.nr23 = st.NR23
+	s.nr24 = st.NR24
+	s.frequencyCounter = int(st.FrequencyCounter)
+	s.envalopeCounter = st.EnvalopeCounter
+	s.volume = st.Volume
+	s.wavePos = st.WavePos
+	s.waveState = st.WaveState
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package audio
+
+import "fmt"
+
+// stemNames are the file name suffixes of the channels
+var stemNames = [4]string{"square1", "square2", "wave", "noise"}
+
+// Stems records the output of each sound channel (before the mixing
+// and panning of the control unit) to its own mono wav file
+type Stems struct {
+	files  [4]*WAV
+	sample [1]byte
+}
+
+// NewStems creates a wav file for each channel, named
+// '<prefix>-square1.wav', '<prefix>-square2.wav',
+// '<prefix>-wave.wav' and '<prefix>-noise.wav'
+func NewStems(prefix string, rate int) (*Stems, error) {
+
+	var s Stems
+
+	for i, name := range stemNames {
+
+		wav, err := NewWAV(fmt.Sprintf("%s-%s.wav", prefix, name), rate, 1)
+
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+
+		s.files[i] = wav
+	}
+
+	return &s, nil
+}
+
+// write a single sample of each channel, the 4 bit
+// output levels are scaled to 8 bit samples
+func (s *Stems) write(levels [4]byte) error {
+
+	for i, wav := range s.files {
+
+		s.sample[0] = levels[i] << 4
+
+		if _, err := wav.Write(s.sample[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close writes the final wav headers and closes the files
+func (s *Stems) Close() error {
+
+	var err error
+
+	for _, wav := range s.files {
+
+		if wav == nil {
+			continue
+		}
+
+		if cerr := wav.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
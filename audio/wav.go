@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// wavHeaderSize is the size of the riff header of a pcm wav file
+const wavHeaderSize = 44
+
+// wavHeader is the riff header of a pcm wav file
+type wavHeader struct {
+	RIFF          [4]byte
+	RIFFSize      uint32
+	WAVE          [4]byte
+	Fmt           [4]byte
+	FmtSize       uint32
+	FormatTag     uint16
+	Channels      uint16
+	SamplesPerSec uint32
+	BytesPerSec   uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	Data          [4]byte
+	DataSize      uint32
+}
+
+// WAV writes 8 bit unsigned pcm samples to a wav file
+type WAV struct {
+	f        *os.File
+	w        *bufio.Writer
+	rate     int
+	channels int
+	size     uint32
+}
+
+// NewWAV creates a wav file with 'channels' interleaved 8 bit
+// channels, sampled 'rate' times per second
+func NewWAV(file string, rate, channels int) (*WAV, error) {
+
+	f, err := os.Create(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAV{f: f, w: bufio.NewWriter(f), rate: rate, channels: channels}
+
+	// the sizes are written again on close
+	if err := w.writeHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write appends samples to the file (buffered)
+func (w *WAV) Write(samples []byte) (int, error) {
+
+	n, err := w.w.Write(samples)
+	w.size += uint32(n)
+
+	return n, err
+}
+
+// Close writes the final sizes and closes the file
+func (w *WAV) Close() error {
+
+	// the data chunk is padded to an even size
+	if w.size%2 != 0 {
+		if err := w.w.WriteByte(0); err != nil {
+			w.f.Close()
+			return err
+		}
+	}
+
+	if err := w.w.Flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	if err := w.writeHeader(); err != nil {
+		w.f.Close()
+		return err
+	}
+
+	return w.f.Close()
+}
+
+// writeHeader writes the riff header with the current data size
+func (w *WAV) writeHeader() error {
+
+	h := wavHeader{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		RIFFSize:      wavHeaderSize - 8 + w.size + w.size%2,
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		FormatTag:     1, // pcm
+		Channels:      uint16(w.channels),
+		SamplesPerSec: uint32(w.rate),
+		BytesPerSec:   uint32(w.rate * w.channels),
+		BlockAlign:    uint16(w.channels),
+		BitsPerSample: 8,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      w.size,
+	}
+
+	return binary.Write(w.f, binary.LittleEndian, &h)
+}
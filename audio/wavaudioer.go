@@ -0,0 +1,36 @@
+package audio
+
+// WAVAudioer is an Audioer that writes the samples to a wav file
+// before queuing them to another audioer
+type WAVAudioer struct {
+	Audioer
+	wav *WAV
+}
+
+// NewWAVAudioer creates a stereo wav file and returns an audioer
+// that writes to it and queues the samples to 'next' (e.g. NullAudioer)
+func NewWAVAudioer(file string, next Audioer) (*WAVAudioer, error) {
+
+	wav, err := NewWAV(file, next.Frequency(), 2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &WAVAudioer{Audioer: next, wav: wav}, nil
+}
+
+// Queue writes the samples and queues them
+func (w *WAVAudioer) Queue(samples []byte) error {
+
+	if _, err := w.wav.Write(samples); err != nil {
+		return err
+	}
+
+	return w.Audioer.Queue(samples)
+}
+
+// Close writes the final wav header and closes the file
+func (w *WAVAudioer) Close() error {
+	return w.wav.Close()
+}
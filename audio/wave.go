@@ -1,6 +1,11 @@
 package audio
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
 
 // AddrNR30 is the NR30 register address
 const AddrNR30 uint16 = 0xFF1A
@@ -23,6 +28,18 @@ const AddrWaveTableStart uint16 = 0xFF30
 // AddrWaveTableEnd is the ending address of the wave table
 const AddrWaveTableEnd uint16 = 0xFF3F
 
+// waveState is the serialized form of the sound channel 3
+type waveState struct {
+	NR30             byte
+	NR31             byte
+	NR32             byte
+	NR33             byte
+	NR34             byte
+	WaveTable        [16]byte
+	FrequencyCounter int32
+	WavePos          byte
+}
+
 // Wave sound channel
 type Wave struct {
 	nr30 byte
@@ -206,3 +223,40 @@ func (w *Wave) currentSample() byte {
 func (w *Wave) dac() bool {
 	return w.nr30&0x80 == 0x80
 }
+
+// SaveState writes the sound channel 3 registers and counters to 'out'
+func (w *Wave) SaveState(out io.Writer) error {
+
+	st := waveState{
+		NR30:             w.nr30,
+		NR31:             w.nr31,
+		NR32:             w.nr32,
+		NR33:             w.nr33,
+		NR34:             w.nr34,
+		WaveTable:        w.waveTable,
+		FrequencyCounter: int32(w.frequencyCounter),
+		WavePos:          w.wavePos}
+
+	return binary.Write(out, binary.LittleEndian, &st)
+}
+
+// LoadState reads the sound channel 3 registers and counters from 'r'
+func (w *Wave) LoadState(r io.Reader) error {
+
+	var st waveState
+
+	if err := binary.Read(r, binary.LittleEndian, &st); err != nil {
+		return err
+	}
+
+	w.nr30 = st.NR30
+	w.nr31 = st.NR31
+	w.nr32 = st.NR32
+	w.nr33 = st.NR33
+	w.nr34 = st.NR34
+	w.waveTable = st.WaveTable
+	w.frequencyCounter = int(st.FrequencyCounter)
+	w.wavePos = st.WavePos
+
+	return nil
+}
@@ -0,0 +1,268 @@
+// gbdis disassembles a game ROM bank by bank
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/game"
+	"github.com/moshenahmias/gopherboy/memory"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bankSize is the size of a switchable rom bank
+const bankSize int = 0x4000
+
+// labels of the rst / interrupt vectors
+var labels = map[uint16]string{
+	0x0000: "rst_00",
+	0x0008: "rst_08",
+	0x0010: "rst_10",
+	0x0018: "rst_18",
+	0x0020: "rst_20",
+	0x0028: "rst_28",
+	0x0030: "rst_30",
+	0x0038: "rst_38",
+	0x0040: "int_vblank",
+	0x0048: "int_stat",
+	0x0050: "int_timer",
+	0x0058: "int_serial",
+	0x0060: "int_joypad",
+	0x0100: "entry"}
+
+// headerField is a data section of the cartridge header
+type headerField struct {
+	addr  uint16
+	label string
+}
+
+// cartridge header fields (the header ends at 0x0150)
+var headerFields = []headerField{
+	{0x0104, "header_logo"},
+	{0x0134, "header_title"},
+	{0x0143, "header_cgb_flag"},
+	{0x0144, "header_new_licensee_code"},
+	{0x0146, "header_sgb_flag"},
+	{0x0147, "header_cartridge_type"},
+	{0x0148, "header_rom_size"},
+	{0x0149, "header_ram_size"},
+	{0x014A, "header_destination_code"},
+	{0x014B, "header_old_licensee_code"},
+	{0x014C, "header_version"},
+	{0x014D, "header_checksum"},
+	{0x014E, "header_global_checksum"}}
+
+func main() {
+
+	// init command-line arguments
+	argROM := flag.String("rom", "", "Path to game ROM")
+	argBank := flag.Int("bank", -1, "Disassemble a single bank (default: all banks)")
+
+	// parse command-line arguments
+	flag.Parse()
+
+	// validate rom file arg
+	if len(*argROM) == 0 {
+		flag.PrintDefaults()
+		return
+	}
+
+	rom, err := ioutil.ReadFile(*argROM)
+
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	if err := disassemble(w, rom, *argBank); err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+}
+
+// disassemble the rom banks to 'w' ('bank' < 0 for all banks)
+func disassemble(w io.Writer, rom []byte, bank int) error {
+
+	banks := (len(rom) + bankSize - 1) / bankSize
+
+	if bank >= banks {
+		return fmt.Errorf("no such bank (%d), the rom has %d banks", bank, banks)
+	}
+
+	if h, err := game.ParseHeader(rom); err == nil {
+		fmt.Fprintf(w, "; %s (%s), %d banks\n", h.Title, h.CartridgeTypeName(), banks)
+	}
+
+	for i := 0; i < banks; i++ {
+
+		if bank < 0 || bank == i {
+			disassembleBank(w, rom, i)
+		}
+	}
+
+	return nil
+}
+
+// disassembleBank writes the instructions of bank 'bank' to 'w'
+func disassembleBank(w io.Writer, rom []byte, bank int) {
+
+	start := bank * bankSize
+	end := start + bankSize
+
+	if end > len(rom) {
+		end = len(rom)
+	}
+
+	// bank 0 is always mapped to 0000-3FFF, the rest to 4000-7FFF
+	base := uint16(0x0000)
+
+	if bank > 0 {
+		base = 0x4000
+	}
+
+	unit := memory.NewROM(rom[start:end], base)
+	last := base + uint16(end-start-1)
+
+	fmt.Fprintf(w, "\n; bank %02x\n", bank)
+
+	for addr := base; addr <= last; {
+
+		if bank == 0 {
+
+			if label, found := labels[addr]; found {
+				fmt.Fprintf(w, "\n%s:\n", label)
+			}
+
+			if i := headerFieldIndex(addr); i >= 0 {
+				addr = dumpHeaderField(w, unit, i)
+				continue
+			}
+		}
+
+		text, n := cpu.Disassemble(unit, addr)
+
+		// the bytes before a label or a header field
+		// that starts inside the instruction are data
+		if bank == 0 {
+			if next := nextBoundary(addr, n); next > addr {
+				n = int(next - addr)
+				text = dataText(unit, addr, n)
+			}
+		}
+
+		fmt.Fprintf(w, "%02x:%04x ", bank, addr)
+
+		for i := 0; i < 3; i++ {
+			if data, err := unit.Read(addr + uint16(i)); err == nil && i < n {
+				fmt.Fprintf(w, " %02x", data)
+			} else {
+				fmt.Fprint(w, "   ")
+			}
+		}
+
+		fmt.Fprintf(w, "  %s\n", text)
+
+		addr += uint16(n)
+	}
+}
+
+// headerFieldIndex returns the index of the header field
+// that starts at address 'addr' (or -1)
+func headerFieldIndex(addr uint16) int {
+
+	for i, field := range headerFields {
+		if field.addr == addr {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// nextBoundary returns the address of the first label or header field
+// that starts inside the 'n' bytes after 'addr' (excluding 'addr'),
+// or 'addr' if there is none
+func nextBoundary(addr uint16, n int) uint16 {
+
+	next := addr
+
+	within := func(a uint16) bool {
+		return a > addr && int(a) < int(addr)+n && (next == addr || a < next)
+	}
+
+	for a := range labels {
+		if within(a) {
+			next = a
+		}
+	}
+
+	for _, field := range headerFields {
+		if within(field.addr) {
+			next = field.addr
+		}
+	}
+
+	return next
+}
+
+// dataText returns the 'n' bytes at 'addr' as a data directive
+func dataText(unit memory.Unit, addr uint16, n int) string {
+
+	text := "DB"
+
+	for i := 0; i < n; i++ {
+
+		data, _ := unit.Read(addr + uint16(i))
+
+		if i > 0 {
+			text += ","
+		}
+
+		text += fmt.Sprintf(" $%02x", data)
+	}
+
+	return text
+}
+
+// dumpHeaderField writes header field 'i' as data,
+// returns the address that follows the field
+func dumpHeaderField(w io.Writer, unit memory.Unit, i int) uint16 {
+
+	field := headerFields[i]
+	end := uint16(game.HeaderSize)
+
+	if i+1 < len(headerFields) {
+		end = headerFields[i+1].addr
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", field.label)
+
+	for addr := field.addr; addr < end; addr += 16 {
+
+		fmt.Fprintf(w, "00:%04x   DB", addr)
+
+		for j := addr; j < end && j < addr+16; j++ {
+
+			data, _ := unit.Read(j)
+
+			if j > addr {
+				fmt.Fprint(w, ",")
+			}
+
+			fmt.Fprintf(w, " $%02x", data)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	return end
+}
@@ -0,0 +1,327 @@
+// gopherboy-headless runs a game without a window, sound or keyboard
+// (no SDL / cgo dependency), which makes it usable in CI environments.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/moshenahmias/gopherboy/audio"
+	"github.com/moshenahmias/gopherboy/config"
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/display"
+	"github.com/moshenahmias/gopherboy/gameboy"
+	"github.com/moshenahmias/gopherboy/joypad"
+	"github.com/moshenahmias/gopherboy/record"
+	"github.com/moshenahmias/gopherboy/serial"
+
+	"github.com/sirupsen/logrus"
+)
+
+// options of a headless run
+type options struct {
+	romFile    string
+	biosFile   string
+	frames     int
+	speed      float64
+	pngFile    string
+	scale      int
+	exitPC     int
+	exitOpcode int
+	keys       string
+	traceFile  string
+	tracePC    string
+	traceBank  int
+	doctor     bool
+	serial     string
+	printDir   string
+	record     string
+	wavFile    string
+	stems      string
+}
+
+func main() {
+
+	// set logging level
+	logrus.SetLevel(logrus.InfoLevel)
+
+	var opts options
+	var exitPC, exitOpcode string
+
+	// init command-line arguments
+	flag.StringVar(&opts.romFile, "rom", "", "Path to game ROM")
+	flag.StringVar(&opts.biosFile, "bios", "", "Path to boot ROM")
+	flag.IntVar(&opts.frames, "frames", 0, "Number of frames to run (0 = unlimited)")
+	flag.Float64Var(&opts.speed, "speed", 0, "Speed multiplier (0 = as fast as possible)")
+	flag.StringVar(&opts.pngFile, "png", "", "Path to PNG file for the final frame")
+	flag.IntVar(&opts.scale, "scale", 1, "Scale of the PNG file")
+	flag.StringVar(&exitPC, "exit-pc", "", "Exit when the PC reaches this address (e.g. 0x0150)")
+	flag.StringVar(&exitOpcode, "exit-opcode", "", "Exit before executing this opcode (e.g. 0x40)")
+	flag.StringVar(&opts.keys, "keys", "", "Keystrokes script, e.g. \"120:+start,130:-start\"")
+	flag.StringVar(&opts.traceFile, "trace", "", "Path to execution trace file")
+	flag.StringVar(&opts.tracePC, "trace-pc", "", "Trace only the given pc range (e.g. 0150-3fff)")
+	flag.IntVar(&opts.traceBank, "trace-bank", -1, "Trace only the given rom bank")
+	flag.BoolVar(&opts.doctor, "trace-doctor", false, "Stub LY to $90 while tracing (for gameboy-doctor)")
+	flag.StringVar(&opts.serial, "serial", "none", "Serial link peer (none, loopback, stdout or printer)")
+	flag.StringVar(&opts.printDir, "print-dir", ".", "Directory of the printer's PNG files (with -serial printer)")
+	flag.StringVar(&opts.record, "record", "", "Record to file (.avi, .y4m + .wav or .gif)")
+	flag.StringVar(&opts.wavFile, "wav", "", "Write the sound to a WAV file")
+	flag.StringVar(&opts.stems, "stems", "", "Write each sound channel to its own WAV file (<prefix>-square1.wav, -square2, -wave and -noise)")
+
+	// parse command-line arguments
+	flag.Parse()
+
+	// validate rom file arg
+	if len(opts.romFile) == 0 {
+		flag.PrintDefaults()
+		return
+	}
+
+	var err error
+
+	if opts.exitPC, err = parseHex(exitPC, 0xFFFF); err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+
+	if opts.exitOpcode, err = parseHex(exitOpcode, 0xFF); err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+
+	// run
+	if err := run(&opts); err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+}
+
+// parseHex parses an optional number (-1 if empty)
+func parseHex(s string, max uint64) (int, error) {
+
+	if len(s) == 0 {
+		return -1, nil
+	}
+
+	v, err := strconv.ParseUint(s, 0, 64)
+
+	if err != nil || v > max {
+		return 0, fmt.Errorf("invalid value (%s)", s)
+	}
+
+	return int(v), nil
+}
+
+func run(opts *options) error {
+
+	// load bios (if available)
+	var biosData []byte
+
+	if len(opts.biosFile) > 0 {
+
+		var err error
+
+		// load bios from file
+		biosData, err = ioutil.ReadFile(opts.biosFile)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	script, err := joypad.ParseScript(opts.keys)
+
+	if err != nil {
+		return err
+	}
+
+	var gb *gameboy.Gameboy
+
+	// stop after the requested number of frames
+	monitor := display.NewMemoryMonitor(func(count int) {
+		if opts.frames > 0 && count >= opts.frames {
+			gb.Stop()
+		}
+	})
+
+	keystroker := joypad.NewScriptedKeystroker(script, monitor.Frames)
+
+	s := &config.DefaultSettings
+	colors := [4]uint32{s.Color_0, s.Color_1, s.Color_2, s.Color_3}
+
+	recorder := record.NewRecorder(colors)
+
+	recorder.SetSplitHandler(func(file string, why error) {
+		logrus.Infof("%s, recording continues in %s", why, file)
+	})
+
+	recorder.SetErrorHandler(func(file string, err error) {
+		logrus.Errorf("recording to %s stopped: %s", file, err)
+	})
+
+	var audioer audio.Audioer = &audio.NullAudioer{}
+
+	if len(opts.wavFile) > 0 {
+
+		wav, err := audio.NewWAVAudioer(opts.wavFile, audioer)
+
+		if err != nil {
+			return err
+		}
+
+		defer wav.Close()
+
+		audioer = wav
+	}
+
+	gb, err = gameboy.Create(opts.romFile, biosData, recorder.Monitor(monitor), recorder.Audioer(audioer), keystroker, 60)
+
+	if err != nil {
+		return err
+	}
+
+	if len(opts.stems) > 0 {
+
+		stems, err := audio.NewStems(opts.stems, audioer.Frequency())
+
+		if err != nil {
+			return err
+		}
+
+		defer stems.Close()
+
+		gb.APU().SetStems(stems)
+	}
+
+	if len(opts.record) > 0 {
+
+		if err := recorder.Start(opts.record); err != nil {
+			return err
+		}
+
+		defer recorder.Stop()
+	}
+
+	var peer serial.Peer
+
+	if opts.serial == "printer" {
+
+		printer := serial.NewPrinter(opts.printDir)
+
+		printer.OnPrint(func(file string, err error) {
+			if err != nil {
+				logrus.Error(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "printed %s\n", file)
+			}
+		})
+
+		defer printer.Flush()
+
+		peer = printer
+
+	} else if peer, err = serial.NewPeer(opts.serial); err != nil {
+		return err
+	}
+
+	gb.Serial().Connect(peer)
+
+	gb.Cartridge().OnSaveError(func(err error) {
+		logrus.Error(err)
+	})
+
+	core := gb.Core()
+
+	core.SetSpeed(opts.speed)
+
+	exitReason := fmt.Sprintf("%d frames", opts.frames)
+
+	if opts.exitPC >= 0 || opts.exitOpcode >= 0 {
+
+		core.SetHook(func(pc uint16, opcode byte) bool {
+
+			if int(pc) == opts.exitPC {
+				exitReason = fmt.Sprintf("pc %04x", pc)
+				return false
+			}
+
+			if int(opcode) == opts.exitOpcode {
+				exitReason = fmt.Sprintf("opcode %02x at %04x", opcode, pc)
+				return false
+			}
+
+			return true
+		})
+	}
+
+	// execution trace
+	if len(opts.traceFile) > 0 {
+
+		f, err := os.Create(opts.traceFile)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		tracer := cpu.NewTracer(f)
+
+		defer tracer.Flush()
+
+		if len(opts.tracePC) > 0 {
+
+			from, to, err := cpu.ParseHexRange(opts.tracePC)
+
+			if err != nil {
+				return err
+			}
+
+			tracer.SetRange(from, to)
+		}
+
+		tracer.SetBank(opts.traceBank)
+		tracer.SetDoctor(opts.doctor)
+		gb.SetTracer(tracer)
+	}
+
+	if err := gb.Start(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "stopped on %s after %d frames\n", exitReason, monitor.Frames())
+
+	// save the battery backed ram
+	if err := gb.Cartridge().Flush(); err != nil {
+		return err
+	}
+
+	// dump the final frame
+	if len(opts.pngFile) > 0 {
+
+		f, err := os.Create(opts.pngFile)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		img := monitor.LastFrame().Image(colors, opts.scale)
+
+		// super game boy games are dumped with their border
+		if b := monitor.Border(); b != nil {
+			img = b.Image(monitor.LastFrame(), colors, opts.scale)
+		}
+
+		if err := png.Encode(f, img); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
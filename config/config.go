@@ -23,6 +23,16 @@ var DefaultSettings = Settings{
 		32:         EJoypad_JoypadSelect,
 		13:         EJoypad_JoypadStart},
 
+	JoypadMapping_2: map[int32]EJoypad{
+		119: EJoypad_JoypadUp,
+		115: EJoypad_JoypadDown,
+		97:  EJoypad_JoypadLeft,
+		100: EJoypad_JoypadRight,
+		103: EJoypad_JoypadA,
+		102: EJoypad_JoypadB,
+		114: EJoypad_JoypadSelect,
+		116: EJoypad_JoypadStart},
+
 	SoundDevice: 0,
 	Fps:         60,
 	Scale:       2,
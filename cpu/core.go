@@ -3,7 +3,11 @@ package cpu
 import (
 	"github.com/moshenahmias/gopherboy/memory"
 
+	"encoding/binary"
 	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,13 +18,19 @@ func noSuchInstructionError(opcode byte) error {
 	return fmt.Errorf("No such instruction %02x", opcode)
 }
 
-// TimedUnit is a cpu cycles observer
+// TimedUnit is a cpu cycles observer, it is clocked for every memory
+// access (4 cycles) as it happens and for the internal cycles of an
+// instruction
 type TimedUnit interface {
 	ClockChanged(cycles int) error
 }
 
 type Instruction func() (int, int, string, error)
 
+// Hook is called before an instruction execution, with the
+// instruction address and opcode
+type Hook func(pc uint16, opcode byte) bool
+
 // Core represents the CPU's core, it includes
 // its registers, access to the MMU and instructions.
 type Core struct {
@@ -43,7 +53,7 @@ type Core struct {
 	instructions   [256]Instruction // instruction set
 	instructionsCB [256]Instruction // cb instruction set
 
-	mmu        memory.Unit   // MMU
+	mmu        *memory.MMU   // MMU
 	ime        bool          // interrupt master enable
 	ier        memory.MemReg // interrupt enable register
 	ifr        memory.MemReg // interrupt flags register
@@ -51,10 +61,39 @@ type Core struct {
 	halt       bool          // halt flag
 	stop       bool          // bool flag
 	timedUnits []TimedUnit   // clocked units
+	fixedUnits []TimedUnit   // clocked units (at the normal speed)
+	speed      speedSwitch   // double speed mode (cgb)
+	elapsed    int           // cycles clocked during the current step
+	stall      int           // cycles to pass without executing (e.g. vram dma)
+
+	sched    scheduler
+	hook     Hook
+	sync     func()
+	debugger *Debugger
+	tracer   *Tracer
+
+	pause   int32      // paused (accessed atomically)
+	advance int32      // run a single frame while paused (accessed atomically)
+	m       sync.Mutex // held while a single step is executed
+}
 
-	throttle int
-
-	pause bool
+// coreState is the serialized form of the core
+type coreState struct {
+	AF   uint16
+	BC   uint16
+	DE   uint16
+	HL   uint16
+	SP   uint16
+	PC   uint16
+	IME  bool
+	IE   byte
+	IF   byte
+	Halt bool
+	Stop bool
+
+	DoubleSpeed bool
+	SpeedSwitch bool
+	Stall       int32
 }
 
 // NewCore creates Core instance
@@ -62,7 +101,8 @@ func NewCore(mmu *memory.MMU) (*Core, error) {
 
 	c := Core{mmu: mmu}
 
-	c.throttle = 5500
+	c.SetSpeed(1)
+	c.SetFrameRate(60)
 
 	c.a = c.af.high()
 	c.f = c.af.low()
@@ -87,19 +127,22 @@ func NewCore(mmu *memory.MMU) (*Core, error) {
 	return &c, nil
 }
 
-// RegisterToClockChanges that take place after every instruction execution
+// RegisterToClockChanges that take place during every instruction execution
 func (c *Core) RegisterToClockChanges(unit TimedUnit) {
 	c.timedUnits = append(c.timedUnits, unit)
 }
 
-// Throttle the cpu speed
-func (c *Core) Throttle(tooFast bool) {
+// SetSyncHook sets a function that is called between two instructions,
+// while the core is unlocked, it may wait (e.g. for a remote gameboy
+// to catch up), nil to remove
+func (c *Core) SetSyncHook(fn func()) {
+	c.sync = fn
+}
 
-	if tooFast {
-		c.throttle += 10
-	} else {
-		c.throttle -= 10
-	}
+// SetHook sets a function that is called before every instruction
+// execution, the execution loop stops when it returns false
+func (c *Core) SetHook(hook Hook) {
+	c.hook = hook
 }
 
 // Start the cpu activity at address 'pc'
@@ -109,11 +152,90 @@ func (c *Core) Start(pc uint16) error {
 
 	for !c.quit {
 
-		for c.pause && !c.quit {
+		for atomic.LoadInt32(&c.pause) != 0 && atomic.LoadInt32(&c.advance) == 0 && !c.quit {
+
+			if c.sched.onPause != nil {
+				c.sched.onPause()
+			}
+
 			time.Sleep(time.Millisecond * 100)
 		}
 
-		opcode, err := c.mmu.Read(c.pc.get())
+		if c.debugger != nil {
+
+			if err := c.debugger.check(); err != nil {
+				return err
+			}
+
+			if c.quit {
+				break
+			}
+		}
+
+		c.m.Lock()
+		err := c.step()
+		c.m.Unlock()
+
+		if err != nil {
+
+			if c.debugger != nil {
+				if derr := c.debugger.failed(err); derr != nil {
+					err = fmt.Errorf("%v (debugger: %v)", err, derr)
+				}
+			}
+
+			return err
+		}
+
+		if c.sync != nil {
+			c.sync()
+		}
+
+		c.pace()
+	}
+
+	return nil
+}
+
+// step executes a single instruction (or a single halted
+// cycle), updates the timed units and handles interrupts
+func (c *Core) step() error {
+
+	cycles := 4
+	c.elapsed = 0
+
+	if c.stall > 0 {
+
+		// the cpu is stalled, only the timed units are clocked
+		cycles = c.stall
+		c.stall = 0
+
+	} else if c.halt || c.stop {
+
+		if _, err := c.mmu.Read(0xFF00); err != nil {
+			return c.wrapError(err, "joyp read (during stop) failed")
+		}
+
+	} else {
+
+		// the tracer and hook see the opcode before it is fetched
+		peeked, err := c.mmu.Peek(c.pc.get())
+
+		if err != nil {
+			return c.wrapError(err, "pc read failed")
+		}
+
+		if c.tracer != nil {
+			c.tracer.trace(c, c.pc.get())
+		}
+
+		if c.hook != nil && !c.hook(c.pc.get(), peeked) {
+			c.quit = true
+			return nil
+		}
+
+		// the opcode fetch cycle, timed like every other access
+		opcode, err := c.fetch(c.pc.get())
 
 		if err != nil {
 			return c.wrapError(err, "pc read failed")
@@ -125,48 +247,180 @@ func (c *Core) Start(pc uint16) error {
 			return c.wrapError(noSuchInstructionError(opcode), "instruction fetch failed")
 		}
 
-		_, cycles, name, err := ins()
+		_, n, name, err := ins()
 
 		if err != nil {
 			return c.wrapErrorf(err, "%s %02x failed", name, opcode)
 		}
 
-		for i := 0; i < c.throttle; i++ {
-			// do absolutely nothing
+		c.pc.increment()
+
+		cycles = n
+	}
+
+	// the internal cycles that were not clocked by memory accesses
+	if cycles > c.elapsed {
+		if err := c.clock(cycles - c.elapsed); err != nil {
+			return err
 		}
+	}
 
-		c.pc.increment()
+	if err := c.handleInterrupts(); err != nil {
+		return c.wrapError(err, "HandleInterrupts() failed")
+	}
 
-		for do := true; do; do = (c.halt || c.stop) && !c.quit {
+	return nil
+}
 
-			for c.pause && !c.quit {
-				time.Sleep(time.Millisecond * 100)
-			}
+// clock notifies the timed units about the passed cycles
+func (c *Core) clock(cycles int) error {
 
-			if c.halt || c.stop {
-				if _, err := c.mmu.Read(0xFF00); err != nil {
-					return c.wrapError(err, "joyp read (during stop) failed")
-				}
-			}
+	fixed := cycles
 
-			for _, u := range c.timedUnits {
-				if err := u.ClockChanged(cycles); err != nil {
-					return c.wrapError(err, "ClockChanged() failed")
-				}
-			}
+	if c.speed.double {
+		fixed = cycles / 2
+	}
 
-			if err := c.handleInterrupts(); err != nil {
-				return c.wrapError(err, "HandleInterrupts() failed")
-			}
+	c.sched.cycles += fixed
+
+	for _, u := range c.timedUnits {
+		if err := u.ClockChanged(cycles); err != nil {
+			return c.wrapError(err, "ClockChanged() failed")
 		}
 	}
 
+	for _, u := range c.fixedUnits {
+		if err := u.ClockChanged(fixed); err != nil {
+			return c.wrapError(err, "ClockChanged() failed")
+		}
+	}
+
+	return nil
+}
+
+// Stall stops the instruction execution for 'cycles' cycles
+// (e.g. during a vram dma), the timed units keep running
+func (c *Core) Stall(cycles int) {
+	c.stall += cycles
+}
+
+// tick clocks the timed units during the current step
+func (c *Core) tick(cycles int) error {
+	c.elapsed += cycles
+	return c.clock(cycles)
+}
+
+// read from address 'addr' in a single memory cycle, the timed
+// units are clocked before the access
+func (c *Core) read(addr uint16) (byte, error) {
+
+	if err := c.tick(4); err != nil {
+		return 0, err
+	}
+
+	return c.mmu.ReadWatched(addr)
+}
+
+// fetch an instruction byte from address 'addr' in a single
+// memory cycle, unlike read, watchpoints are not triggered
+func (c *Core) fetch(addr uint16) (byte, error) {
+
+	if err := c.tick(4); err != nil {
+		return 0, err
+	}
+
+	return c.mmu.Read(addr)
+}
+
+// write 'data' to address 'addr' in a single memory cycle, the timed
+// units are clocked before the access
+func (c *Core) write(addr uint16, data byte) error {
+
+	if err := c.tick(4); err != nil {
+		return err
+	}
+
+	return c.mmu.WriteWatched(addr, data)
+}
+
+// Exec runs 'fn' between two instructions, while
+// the core's execution loop is blocked
+func (c *Core) Exec(fn func() error) error {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return fn()
+}
+
+// SaveState writes the core registers and flags to 'w'
+func (c *Core) SaveState(w io.Writer) error {
+
+	s := coreState{
+		AF:   c.af.get(),
+		BC:   c.bc.get(),
+		DE:   c.de.get(),
+		HL:   c.hl.get(),
+		SP:   c.sp.get(),
+		PC:   c.pc.get(),
+		IME:  c.ime,
+		IE:   byte(c.ier),
+		IF:   byte(c.ifr),
+		Halt: c.halt,
+		Stop: c.stop,
+
+		DoubleSpeed: c.speed.double,
+		SpeedSwitch: c.speed.prepared,
+		Stall:       int32(c.stall)}
+
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// LoadState reads the core registers and flags from 'r'
+func (c *Core) LoadState(r io.Reader) error {
+
+	var s coreState
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	c.af.set(s.AF)
+	c.bc.set(s.BC)
+	c.de.set(s.DE)
+	c.hl.set(s.HL)
+	c.sp.set(s.SP)
+	c.pc.set(s.PC)
+	c.ime = s.IME
+	c.ier = memory.MemReg(s.IE)
+	c.ifr = memory.MemReg(s.IF)
+	c.halt = s.Halt
+	c.stop = s.Stop
+	c.speed.double = s.DoubleSpeed
+	c.speed.prepared = s.SpeedSwitch
+	c.stall = int(s.Stall)
+
 	return nil
 }
 
-// Pause the cpu (or resume if already paused)
+// Pause the cpu (or resume if already paused), safe
+// to call from any goroutine
 func (c *Core) Pause() {
-	c.pause = !c.pause
+
+	for {
+		p := atomic.LoadInt32(&c.pause)
+
+		if atomic.CompareAndSwapInt32(&c.pause, p, p^1) {
+			return
+		}
+	}
+}
+
+// AdvanceFrame runs a single frame and pauses the cpu (pauses at
+// the end of the frame if running), safe to call from any goroutine
+func (c *Core) AdvanceFrame() {
+	atomic.StoreInt32(&c.advance, 1)
+	atomic.StoreInt32(&c.pause, 1)
 }
 
 // Stop the execition loop
@@ -202,7 +456,7 @@ func (c *Core) wrapError(err error, message string) error {
 func (c *Core) loadImmediate8() (byte, error) {
 
 	c.pc.increment()
-	val, err := c.mmu.Read(c.pc.get())
+	val, err := c.fetch(c.pc.get())
 
 	if err != nil {
 		return 0, err
@@ -215,14 +469,14 @@ func (c *Core) loadImmediate8() (byte, error) {
 func (c *Core) loadImmediate16() (uint16, error) {
 
 	c.pc.increment()
-	lval, err := c.mmu.Read(c.pc.get())
+	lval, err := c.fetch(c.pc.get())
 
 	if err != nil {
 		return 0, err
 	}
 
 	c.pc.increment()
-	hval, err := c.mmu.Read(c.pc.get())
+	hval, err := c.fetch(c.pc.get())
 
 	if err != nil {
 		return 0, err
@@ -0,0 +1,565 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// ErrInvalidCondition is returned for a malformed breakpoint condition
+var ErrInvalidCondition = errors.New("ErrInvalidCondition")
+
+// Registers is a snapshot of the cpu registers and flags
+type Registers struct {
+	A    byte   // register A
+	F    byte   // register F
+	B    byte   // register B
+	C    byte   // register C
+	D    byte   // register D
+	E    byte   // register E
+	H    byte   // register H
+	L    byte   // register L
+	SP   uint16 // stack pointer
+	PC   uint16 // program counter
+	IME  bool   // interrupt master enable
+	IE   byte   // interrupt enable register
+	IF   byte   // interrupt flags register
+	Halt bool   // halt flag
+	Stop bool   // stop flag
+}
+
+// Flags returns the ZNHC flags as text ('-' for a reset flag)
+func (r *Registers) Flags() string {
+
+	flags := []byte("ZNHC")
+
+	for i := range flags {
+		if r.F&(0x80>>uint(i)) == 0 {
+			flags[i] = '-'
+		}
+	}
+
+	return string(flags)
+}
+
+// String returns the registers as text
+func (r *Registers) String() string {
+
+	return fmt.Sprintf(
+		"A: %02x F: %s BC: %02x%02x DE: %02x%02x HL: %02x%02x SP: %04x PC: %04x IME: %t IE: %02x IF: %02x HALT: %t STOP: %t",
+		r.A, r.Flags(), r.B, r.C, r.D, r.E, r.H, r.L, r.SP, r.PC, r.IME, r.IE, r.IF, r.Halt, r.Stop)
+}
+
+// Registers returns a snapshot of the registers and flags
+func (c *Core) Registers() Registers {
+
+	return Registers{
+		A:    c.a.get(),
+		F:    c.f.get(),
+		B:    c.b.get(),
+		C:    c.c.get(),
+		D:    c.d.get(),
+		E:    c.e.get(),
+		H:    c.h.get(),
+		L:    c.l.get(),
+		SP:   c.sp.get(),
+		PC:   c.pc.get(),
+		IME:  c.ime,
+		IE:   byte(c.ier),
+		IF:   byte(c.ifr),
+		Halt: c.halt,
+		Stop: c.stop}
+}
+
+// SetRegister sets a register (a, f, b, c, d, e, h, l, af, bc, de,
+// hl, sp, pc) or a flag (zf, nf, hf, cf) by name
+func (c *Core) SetRegister(name string, value uint16) error {
+
+	_, set, err := c.registerByName(name)
+
+	if err != nil {
+		return err
+	}
+
+	set(value)
+
+	return nil
+}
+
+// registerByName returns the getter and setter of a register or a flag
+func (c *Core) registerByName(name string) (func() uint16, func(uint16), error) {
+
+	r8 := func(r *Register8, mask byte) (func() uint16, func(uint16), error) {
+		return func() uint16 { return uint16(r.get()) },
+			func(v uint16) { r.set(byte(v) & mask) }, nil
+	}
+
+	r16 := func(r *Register16, mask uint16) (func() uint16, func(uint16), error) {
+		return func() uint16 { return r.get() },
+			func(v uint16) { r.set(v & mask) }, nil
+	}
+
+	flag := func(get func() bool, set func(bool)) (func() uint16, func(uint16), error) {
+		return func() uint16 {
+				if get() {
+					return 1
+				}
+				return 0
+			},
+			func(v uint16) { set(v != 0) }, nil
+	}
+
+	switch strings.ToLower(name) {
+	case "a":
+		return r8(c.a, 0xFF)
+	case "f":
+		return r8(c.f, 0xF0)
+	case "b":
+		return r8(c.b, 0xFF)
+	case "c":
+		return r8(c.c, 0xFF)
+	case "d":
+		return r8(c.d, 0xFF)
+	case "e":
+		return r8(c.e, 0xFF)
+	case "h":
+		return r8(c.h, 0xFF)
+	case "l":
+		return r8(c.l, 0xFF)
+	case "af":
+		return r16(&c.af, 0xFFF0)
+	case "bc":
+		return r16(&c.bc, 0xFFFF)
+	case "de":
+		return r16(&c.de, 0xFFFF)
+	case "hl":
+		return r16(&c.hl, 0xFFFF)
+	case "sp":
+		return r16(&c.sp, 0xFFFF)
+	case "pc":
+		return r16(&c.pc, 0xFFFF)
+	case "zf":
+		return flag(c.zeroFlag, c.setZeroFlag)
+	case "nf":
+		return flag(c.subtractFlag, c.setSubtractFlag)
+	case "hf":
+		return flag(c.halfCarryFlag, c.setHalfCarryFlag)
+	case "cf":
+		return flag(c.carryFlag, c.setCarryFlag)
+	}
+
+	return nil, nil, fmt.Errorf("no such register (%s)", name)
+}
+
+// comparison of a register (or a flag) with a value
+type comparison struct {
+	get   func() uint16
+	op    string
+	value uint16
+}
+
+// holds returns the comparison result
+func (cmp *comparison) holds() bool {
+
+	v := cmp.get()
+
+	switch cmp.op {
+	case "==":
+		return v == cmp.value
+	case "!=":
+		return v != cmp.value
+	case "<":
+		return v < cmp.value
+	case "<=":
+		return v <= cmp.value
+	case ">":
+		return v > cmp.value
+	case ">=":
+		return v >= cmp.value
+	}
+
+	return false
+}
+
+// Breakpoint breaks the execution when the pc reaches
+// Addr and the condition (if any) is true
+type Breakpoint struct {
+	Addr        uint16
+	Condition   string
+	comparisons []comparison
+}
+
+// hit returns true when the breakpoint's condition is true
+func (bp *Breakpoint) hit() bool {
+
+	for i := range bp.comparisons {
+		if !bp.comparisons[i].holds() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BreakHandler is called (from the execution loop) when the execution
+// breaks, the execution resumes according to the last call to Step,
+// StepOver, RunTo or Continue (the default is Step)
+type BreakHandler func(d *Debugger, reason string) error
+
+// resume modes
+const (
+	resumeStep byte = iota
+	resumeStepOver
+	resumeRunTo
+	resumeContinue
+)
+
+// Debugger controls the execution of an attached core
+type Debugger struct {
+	core        *Core
+	mmu         *memory.MMU
+	handler     BreakHandler
+	breakpoints map[uint16]*Breakpoint
+	interrupt   int32  // break request from another goroutine
+	watchHit    string // triggered watchpoint description
+	resume      byte   // resume mode
+	target      uint16 // run to / step over address
+	targetSP    uint16 // step over stack pointer
+}
+
+// NewDebugger attaches a debugger to 'core', the execution
+// breaks before the first instruction
+func NewDebugger(core *Core, mmu *memory.MMU, handler BreakHandler) *Debugger {
+
+	d := &Debugger{
+		core:        core,
+		mmu:         mmu,
+		handler:     handler,
+		breakpoints: make(map[uint16]*Breakpoint)}
+
+	mmu.SetWatchHandler(d.watchTriggered)
+	core.debugger = d
+
+	return d
+}
+
+// Registers returns a snapshot of the registers and flags
+func (d *Debugger) Registers() Registers {
+	return d.core.Registers()
+}
+
+// SetRegister sets a register or a flag by name
+func (d *Debugger) SetRegister(name string, value uint16) error {
+	return d.core.SetRegister(name, value)
+}
+
+// Peek reads from memory without triggering watchpoints
+func (d *Debugger) Peek(addr uint16) (byte, error) {
+	return d.mmu.Peek(addr)
+}
+
+// Disassemble the instruction at address 'addr'
+func (d *Debugger) Disassemble(addr uint16) (string, int) {
+	return Disassemble(peeker{d.mmu}, addr)
+}
+
+// peeker reads memory without triggering watchpoints
+type peeker struct {
+	mmu *memory.MMU
+}
+
+// Read from address 'addr'
+func (p peeker) Read(addr uint16) (byte, error) {
+	return p.mmu.Peek(addr)
+}
+
+// Write 'data' to address 'addr' (ignored)
+func (p peeker) Write(addr uint16, data byte) error {
+	return nil
+}
+
+// Step resumes the execution for a single instruction
+func (d *Debugger) Step() {
+	d.resume = resumeStep
+}
+
+// StepOver resumes the execution for a single instruction, calls
+// (and restarts) are executed until they return
+func (d *Debugger) StepOver() error {
+
+	pc := d.core.pc.get()
+	opcode, err := d.mmu.Peek(pc)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+
+	case opcode == 0xCD || opcode&0xE7 == 0xC4:
+
+		// call a16 / call cc,a16
+		d.stepOver(pc + 3)
+
+	case opcode&0xC7 == 0xC7:
+
+		// rst
+		d.stepOver(pc + 1)
+
+	default:
+
+		d.resume = resumeStep
+	}
+
+	return nil
+}
+
+// stepOver resumes the execution until the pc reaches 'ret'
+// with the current stack
+func (d *Debugger) stepOver(ret uint16) {
+	d.resume = resumeStepOver
+	d.target = ret
+	d.targetSP = d.core.sp.get()
+}
+
+// RunTo resumes the execution until the pc reaches 'addr'
+// (or a breakpoint / watchpoint is hit)
+func (d *Debugger) RunTo(addr uint16) {
+	d.resume = resumeRunTo
+	d.target = addr
+}
+
+// Continue resumes the execution until a breakpoint / watchpoint is hit
+func (d *Debugger) Continue() {
+	d.resume = resumeContinue
+}
+
+// Break the execution before the next instruction,
+// safe to call from any goroutine
+func (d *Debugger) Break() {
+	atomic.StoreInt32(&d.interrupt, 1)
+}
+
+// Stop the execution loop
+func (d *Debugger) Stop() {
+	d.core.Stop()
+}
+
+// Stopped returns true if the execution loop was stopped
+func (d *Debugger) Stopped() bool {
+	return d.core.quit
+}
+
+// SetBreakpoint at address 'addr', the optional condition is a list of
+// comparisons joined with '&&', e.g. "a == 10 && zf == 1" (hex values)
+func (d *Debugger) SetBreakpoint(addr uint16, condition string) error {
+
+	bp := Breakpoint{Addr: addr, Condition: strings.TrimSpace(condition)}
+
+	if len(bp.Condition) > 0 {
+
+		for _, expr := range strings.Split(bp.Condition, "&&") {
+
+			cmp, err := d.parseComparison(expr)
+
+			if err != nil {
+				return err
+			}
+
+			bp.comparisons = append(bp.comparisons, cmp)
+		}
+	}
+
+	d.breakpoints[addr] = &bp
+
+	return nil
+}
+
+// parseComparison parses "<register> <op> <hex value>"
+func (d *Debugger) parseComparison(expr string) (comparison, error) {
+
+	fields := strings.Fields(expr)
+
+	if len(fields) != 3 {
+		return comparison{}, ErrInvalidCondition
+	}
+
+	get, _, err := d.core.registerByName(fields[0])
+
+	if err != nil {
+		return comparison{}, err
+	}
+
+	switch fields[1] {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return comparison{}, ErrInvalidCondition
+	}
+
+	value, err := ParseHex(fields[2])
+
+	if err != nil {
+		return comparison{}, err
+	}
+
+	return comparison{get: get, op: fields[1], value: value}, nil
+}
+
+// ClearBreakpoint removes the breakpoint at address 'addr'
+func (d *Debugger) ClearBreakpoint(addr uint16) bool {
+
+	_, found := d.breakpoints[addr]
+	delete(d.breakpoints, addr)
+
+	return found
+}
+
+// Breakpoints returns the breakpoints, sorted by address
+func (d *Debugger) Breakpoints() []Breakpoint {
+
+	var bps []Breakpoint
+
+	for _, bp := range d.breakpoints {
+		bps = append(bps, *bp)
+	}
+
+	sort.Slice(bps, func(i, j int) bool { return bps[i].Addr < bps[j].Addr })
+
+	return bps
+}
+
+// Watch memory reads and / or writes in the range 'from -> to'
+func (d *Debugger) Watch(from, to uint16, read, write bool) error {
+	return d.mmu.Watch(memory.Watchpoint{From: from, To: to, Read: read, Write: write})
+}
+
+// Unwatch removes the watchpoint that starts at 'from'
+func (d *Debugger) Unwatch(from uint16) bool {
+	return d.mmu.Unwatch(from)
+}
+
+// Watchpoints returns the watchpoints
+func (d *Debugger) Watchpoints() []memory.Watchpoint {
+	return d.mmu.Watchpoints()
+}
+
+// watchTriggered is called by the mmu (during an instruction execution)
+func (d *Debugger) watchTriggered(wp memory.Watchpoint, addr uint16, data byte, write bool) {
+
+	if write {
+		d.watchHit = fmt.Sprintf("watchpoint: %02x written to %04x", data, addr)
+	} else {
+		d.watchHit = fmt.Sprintf("watchpoint: %02x read from %04x", data, addr)
+	}
+}
+
+// check is called before every instruction, it calls
+// the break handler when the execution should break
+func (d *Debugger) check() error {
+
+	if reason := d.breakReason(); len(reason) > 0 {
+		d.resume = resumeStep
+		return d.handler(d, reason)
+	}
+
+	return nil
+}
+
+// failed calls the break handler after a failed instruction
+func (d *Debugger) failed(err error) error {
+	return d.handler(d, err.Error())
+}
+
+// breakReason returns the reason to break the execution (or "")
+func (d *Debugger) breakReason() string {
+
+	if atomic.SwapInt32(&d.interrupt, 0) != 0 {
+		return "interrupted"
+	}
+
+	if len(d.watchHit) > 0 {
+		reason := d.watchHit
+		d.watchHit = ""
+		return reason
+	}
+
+	c := d.core
+
+	// wait for the cpu to wake up
+	if c.halt || c.stop {
+		return ""
+	}
+
+	pc := c.pc.get()
+
+	switch d.resume {
+
+	case resumeStep:
+
+		return "step"
+
+	case resumeStepOver:
+
+		if pc == d.target && c.sp.get() >= d.targetSP {
+			return "step"
+		}
+
+	case resumeRunTo:
+
+		if pc == d.target {
+			return fmt.Sprintf("reached %04x", pc)
+		}
+	}
+
+	if bp, found := d.breakpoints[pc]; found && bp.hit() {
+		return fmt.Sprintf("breakpoint at %04x", pc)
+	}
+
+	return ""
+}
+
+// ParseHex parses a 16 bit hex value (with an optional "0x" or "$" prefix)
+func ParseHex(s string) (uint16, error) {
+
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "0x"), "$")
+
+	v, err := strconv.ParseUint(s, 16, 16)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex value (%s)", s)
+	}
+
+	return uint16(v), nil
+}
+
+// ParseHexRange parses "<from>-<to>" (or a single address) hex values
+func ParseHexRange(s string) (uint16, uint16, error) {
+
+	bounds := strings.SplitN(s, "-", 2)
+
+	from, err := ParseHex(bounds[0])
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to := from
+
+	if len(bounds) == 2 {
+
+		if to, err = ParseHex(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if from > to {
+		return 0, 0, fmt.Errorf("invalid range (%s)", s)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,230 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// operand is the kind of an instruction's immediate operand
+type operand int
+
+const (
+	operandNone    operand = iota // no immediate operand
+	operandD8                     // 8 bit immediate data (d8)
+	operandD16                    // 16 bit immediate data (d16)
+	operandA8                     // 8 bit offset from $ff00 (a8)
+	operandA16                    // 16 bit address (a16)
+	operandS8                     // 8 bit signed data (r8)
+	operandOffset8                // 8 bit signed offset (+ r8)
+	operandRel8                   // 8 bit signed jump offset (r8)
+)
+
+// operandNames are the operand placeholders in the opcodes mnemonics
+var operandNames = map[operand]string{
+	operandD8:      "d8",
+	operandD16:     "d16",
+	operandA8:      "a8",
+	operandA16:     "a16",
+	operandS8:      "r8",
+	operandOffset8: "+ r8",
+	operandRel8:    "r8",
+}
+
+// opcode describes an instruction for the disassembler
+type opcode struct {
+	mnemonic string  // the operand is written as in the comments above
+	operand  operand // the kind of the immediate operand
+	length   int     // length in bytes (including the prefix)
+}
+
+// codeUnit serves the bytes of a single instruction
+// (zero anywhere else), writes are ignored
+type codeUnit struct {
+	data [2]byte
+}
+
+// Read from address 'addr'
+func (u *codeUnit) Read(addr uint16) (byte, error) {
+
+	if addr < uint16(len(u.data)) {
+		return u.data[addr], nil
+	}
+
+	return 0, nil
+}
+
+// Write 'data' to address 'addr'
+func (u *codeUnit) Write(addr uint16, data byte) error {
+	return nil
+}
+
+// opcodes is the instruction set, an empty mnemonic marks an illegal
+// opcode, the instructions after the CB prefix are found in opcodesCB,
+// both are built once from the instruction tables
+var opcodes, opcodesCB [256]opcode
+
+var opcodesOnce sync.Once
+
+// buildOpcodes executes every instruction of the tables once, on a private
+// core over a code unit, that's how the instructions length and mnemonic
+// are found (the core is discarded afterwards)
+func buildOpcodes() {
+
+	var code codeUnit
+
+	mmu := memory.NewMMU()
+	c, _ := NewCore(mmu)
+	mmu.Map(&code, 0x0000, 0xFFFF)
+
+	describe := func(ins Instruction, data [2]byte) opcode {
+
+		if ins == nil {
+			return opcode{length: 1}
+		}
+
+		code.data = data
+		c.pc.set(0x0000)
+		c.sp.set(0x8000)
+
+		n, _, name, err := ins()
+
+		if err != nil {
+			return opcode{length: 1}
+		}
+
+		return opcode{mnemonic: name, operand: operandOf(name), length: n}
+	}
+
+	for i := range opcodes {
+		if i != 0xCB {
+			opcodes[i] = describe(c.instructions[i], [2]byte{byte(i)})
+		}
+	}
+
+	for i := range opcodesCB {
+		opcodesCB[i] = describe(c.instructions[0xCB], [2]byte{0xCB, byte(i)})
+	}
+}
+
+// operandOf returns the kind of the immediate operand in 'mnemonic'
+func operandOf(mnemonic string) operand {
+
+	switch {
+	case strings.Contains(mnemonic, "d16"):
+		return operandD16
+	case strings.Contains(mnemonic, "a16"):
+		return operandA16
+	case strings.Contains(mnemonic, "d8"):
+		return operandD8
+	case strings.Contains(mnemonic, "a8"):
+		return operandA8
+	case strings.HasPrefix(mnemonic, "JR"):
+		return operandRel8
+	case strings.Contains(mnemonic, "+ r8"):
+		return operandOffset8
+	case strings.Contains(mnemonic, "r8"):
+		return operandS8
+	}
+
+	return operandNone
+}
+
+// decode the instruction in 'data', returns its opcode description
+func decode(data [3]byte) (opcode, bool) {
+
+	opcodesOnce.Do(buildOpcodes)
+
+	op := opcodes[data[0]]
+
+	if data[0] == 0xCB {
+		op = opcodesCB[data[1]]
+	}
+
+	return op, op.mnemonic != ""
+}
+
+// formatOperand returns the text of the immediate operand of 'op'
+// in 'data', for the instruction at address 'addr'
+func formatOperand(op opcode, addr uint16, data [3]byte) string {
+
+	d16 := uint16(data[2])<<8 | uint16(data[1])
+	r8 := int8(data[1])
+
+	switch op.operand {
+
+	case operandD8:
+
+		return fmt.Sprintf("$%02x", data[1])
+
+	case operandD16, operandA16:
+
+		return fmt.Sprintf("$%04x", d16)
+
+	case operandA8:
+
+		return fmt.Sprintf("$ff%02x", data[1])
+
+	case operandS8:
+
+		if r8 < 0 {
+			return fmt.Sprintf("-$%02x", -int(r8))
+		}
+
+		return fmt.Sprintf("$%02x", r8)
+
+	case operandOffset8:
+
+		if r8 < 0 {
+			return fmt.Sprintf("- $%02x", -int(r8))
+		}
+
+		return fmt.Sprintf("+ $%02x", r8)
+
+	case operandRel8:
+
+		// relative jump, show the target address
+		return fmt.Sprintf("$%04x", addr+uint16(op.length)+uint16(r8))
+	}
+
+	return ""
+}
+
+// Disassemble the instruction at address 'addr', returns its text and
+// length in bytes (the instruction is not executed, 'mem' is only read)
+func Disassemble(mem memory.Unit, addr uint16) (text string, length int) {
+
+	var data [3]byte
+	var valid int
+
+	for ; valid < len(data); valid++ {
+
+		b, err := mem.Read(addr + uint16(valid))
+
+		if err != nil {
+			break
+		}
+
+		data[valid] = b
+	}
+
+	if valid == 0 {
+		return "??", 1
+	}
+
+	op, ok := decode(data)
+
+	if !ok || op.length > valid {
+		return fmt.Sprintf("DB $%02x", data[0]), 1
+	}
+
+	if op.operand == operandNone {
+		return op.mnemonic, op.length
+	}
+
+	text = strings.Replace(op.mnemonic, operandNames[op.operand], formatOperand(op, addr, data), 1)
+
+	return text, op.length
+}
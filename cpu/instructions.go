@@ -45,7 +45,12 @@ func (c *Core) initInstructions() {
 	// STOP 0
 	c.instructions[0x10] = func() (int, int, string, error) {
 		c.pc.increment()
-		c.stop = true
+
+		// a prepared speed switch takes place instead of stopping (cgb)
+		if !c.switchSpeed() {
+			c.stop = true
+		}
+
 		return 2, 4, "STOP", nil
 	}
 
@@ -88,11 +93,11 @@ func (c *Core) initInstructions() {
 			return 3, 20, "LD (a16), SP", err
 		}
 
-		if err := c.mmu.Write(im16, c.sp.lowByte()); err != nil {
+		if err := c.write(im16, c.sp.lowByte()); err != nil {
 			return 3, 20, "LD (a16), SP", err
 		}
 
-		return 3, 20, "LD (a16), SP", c.mmu.Write(im16+1, c.sp.highByte())
+		return 3, 20, "LD (a16), SP", c.write(im16+1, c.sp.highByte())
 	}
 
 	// LD HL, SP + r8
@@ -256,18 +261,18 @@ func (c *Core) initInstructions() {
 
 	// LD (BC), A
 	c.instructions[0x02] = func() (int, int, string, error) {
-		return 1, 8, "LD (BC), A", c.mmu.Write(c.bc.get(), c.a.get())
+		return 1, 8, "LD (BC), A", c.write(c.bc.get(), c.a.get())
 	}
 
 	// LD (DE), A
 	c.instructions[0x12] = func() (int, int, string, error) {
-		return 1, 8, "LD (DE), A", c.mmu.Write(c.de.get(), c.a.get())
+		return 1, 8, "LD (DE), A", c.write(c.de.get(), c.a.get())
 	}
 
 	// LD (HL+), A
 	c.instructions[0x22] = func() (int, int, string, error) {
 
-		if err := c.mmu.Write(c.hl.get(), c.a.get()); err != nil {
+		if err := c.write(c.hl.get(), c.a.get()); err != nil {
 			return 1, 8, "LD (HL+), A", err
 		}
 
@@ -279,7 +284,7 @@ func (c *Core) initInstructions() {
 	// LD (HL-), A
 	c.instructions[0x32] = func() (int, int, string, error) {
 
-		if err := c.mmu.Write(c.hl.get(), c.a.get()); err != nil {
+		if err := c.write(c.hl.get(), c.a.get()); err != nil {
 			return 1, 8, "LD (HL-), A", err
 		}
 
@@ -332,7 +337,7 @@ func (c *Core) initInstructions() {
 			return 2, 12, "LD (HL), d8", err
 		}
 
-		return 2, 12, "LD (HL), d8", c.mmu.Write(c.hl.get(), im8)
+		return 2, 12, "LD (HL), d8", c.write(c.hl.get(), im8)
 	}
 
 	// LD A, (BC)
@@ -665,7 +670,7 @@ func (c *Core) initInstructions() {
 	// LD A, C
 	c.instructions[0x79] = func() (int, int, string, error) {
 		c.a.set(c.c.get())
-		return 1, 4, "LD A C", nil
+		return 1, 4, "LD A, C", nil
 	}
 
 	// LD A, D
@@ -735,37 +740,37 @@ func (c *Core) initInstructions() {
 
 	// LD (HL), B
 	c.instructions[0x70] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), B", c.mmu.Write(c.hl.get(), c.b.get())
+		return 1, 8, "LD (HL), B", c.write(c.hl.get(), c.b.get())
 	}
 
 	// LD (HL), C
 	c.instructions[0x71] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), C", c.mmu.Write(c.hl.get(), c.c.get())
+		return 1, 8, "LD (HL), C", c.write(c.hl.get(), c.c.get())
 	}
 
 	// LD (HL), D
 	c.instructions[0x72] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), D", c.mmu.Write(c.hl.get(), c.d.get())
+		return 1, 8, "LD (HL), D", c.write(c.hl.get(), c.d.get())
 	}
 
 	// LD (HL), E
 	c.instructions[0x73] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), E", c.mmu.Write(c.hl.get(), c.e.get())
+		return 1, 8, "LD (HL), E", c.write(c.hl.get(), c.e.get())
 	}
 
 	// LD (HL), H
 	c.instructions[0x74] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), H", c.mmu.Write(c.hl.get(), c.h.get())
+		return 1, 8, "LD (HL), H", c.write(c.hl.get(), c.h.get())
 	}
 
 	// LD (HL), L
 	c.instructions[0x75] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), L", c.mmu.Write(c.hl.get(), c.l.get())
+		return 1, 8, "LD (HL), L", c.write(c.hl.get(), c.l.get())
 	}
 
 	// LD (HL), A
 	c.instructions[0x77] = func() (int, int, string, error) {
-		return 1, 8, "LD (HL), A", c.mmu.Write(c.hl.get(), c.a.get())
+		return 1, 8, "LD (HL), A", c.write(c.hl.get(), c.a.get())
 	}
 
 	// LDH (a8), A
@@ -777,7 +782,7 @@ func (c *Core) initInstructions() {
 			return 2, 12, "LDH (a8), A", err
 		}
 
-		return 2, 12, "LDH (a8), A", c.mmu.Write(0xFF00+uint16(im8), c.a.get())
+		return 2, 12, "LDH (a8), A", c.write(0xFF00+uint16(im8), c.a.get())
 	}
 
 	// LDH A, (a8)
@@ -789,7 +794,7 @@ func (c *Core) initInstructions() {
 			return 2, 12, "LDH A, (a8)", err
 		}
 
-		v, err := c.mmu.Read(0xFF00 + uint16(im8))
+		v, err := c.read(0xFF00 + uint16(im8))
 
 		if err != nil {
 			return 2, 12, "LDH A, (a8)", err
@@ -802,13 +807,13 @@ func (c *Core) initInstructions() {
 
 	// LD (C), A
 	c.instructions[0xE2] = func() (int, int, string, error) {
-		return 2, 8, "LD (C), A", c.mmu.Write(0xFF00+uint16(c.c.get()), c.a.get())
+		return 1, 8, "LD (C), A", c.write(0xFF00+uint16(c.c.get()), c.a.get())
 	}
 
 	// LD A, (C)
 	c.instructions[0xF2] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(0xFF00 + uint16(c.c.get()))
+		v, err := c.read(0xFF00 + uint16(c.c.get()))
 
 		if err != nil {
 			return 1, 8, "LD A, (C)", err
@@ -816,7 +821,7 @@ func (c *Core) initInstructions() {
 
 		c.a.set(v)
 
-		return 2, 8, "LD A, (C)", nil
+		return 1, 8, "LD A, (C)", nil
 	}
 
 	// LD (a16), A
@@ -828,7 +833,7 @@ func (c *Core) initInstructions() {
 			return 3, 16, "LD (a16), A", err
 		}
 
-		return 3, 16, "LD (a16), A", c.mmu.Write(im16, c.a.get())
+		return 3, 16, "LD (a16), A", c.write(im16, c.a.get())
 	}
 
 	// LD A, (a16)
@@ -886,7 +891,7 @@ func (c *Core) initInstructions() {
 	// ADD A, (HL)
 	c.instructions[0x86] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "ADD A, (HL)", err
@@ -956,7 +961,7 @@ func (c *Core) initInstructions() {
 	// ADC A, (HL)
 	c.instructions[0x8E] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "ADC A, (HL)", err
@@ -1026,7 +1031,7 @@ func (c *Core) initInstructions() {
 	// SUB (HL)
 	c.instructions[0x96] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "SUB (HL)", err
@@ -1096,7 +1101,7 @@ func (c *Core) initInstructions() {
 	// SBC A, (HL)
 	c.instructions[0x9E] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "SBC A, (HL)", err
@@ -1166,7 +1171,7 @@ func (c *Core) initInstructions() {
 	// AND (HL)
 	c.instructions[0xA6] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "AND (HL)", err
@@ -1236,7 +1241,7 @@ func (c *Core) initInstructions() {
 	// XOR (HL)
 	c.instructions[0xAE] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "XOR (HL)", err
@@ -1306,7 +1311,7 @@ func (c *Core) initInstructions() {
 	// OR (HL)
 	c.instructions[0xB6] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "OR (HL)", err
@@ -1376,7 +1381,7 @@ func (c *Core) initInstructions() {
 	// CP (HL)
 	c.instructions[0xBE] = func() (int, int, string, error) {
 
-		v, err := c.mmu.Read(c.hl.get())
+		v, err := c.read(c.hl.get())
 
 		if err != nil {
 			return 1, 8, "CP (HL)", err
@@ -1453,7 +1458,7 @@ func (c *Core) initInstructions() {
 	c.instructions[0x34] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 1, 12, "INC (HL)", err
@@ -1464,7 +1469,7 @@ func (c *Core) initInstructions() {
 		v++
 		c.setZeroFlag(v == 0)
 
-		return 1, 12, "INC (HL)", c.mmu.Write(hl, v)
+		return 1, 12, "INC (HL)", c.write(hl, v)
 	}
 
 	// DEC B
@@ -1513,7 +1518,7 @@ func (c *Core) initInstructions() {
 	c.instructions[0x35] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 1, 12, "DEC (HL)", err
@@ -1524,7 +1529,7 @@ func (c *Core) initInstructions() {
 		v--
 		c.setZeroFlag(v == 0)
 
-		return 1, 12, "DEC (HL)", c.mmu.Write(hl, v)
+		return 1, 12, "DEC (HL)", c.write(hl, v)
 	}
 
 	// DAA
@@ -1694,6 +1699,11 @@ func (c *Core) initInstructions() {
 
 		nz := !c.zeroFlag()
 
+		// the condition check takes a cycle
+		if err := c.tick(4); err != nil {
+			return 1, 8, "RET NZ", err
+		}
+
 		if err := c.insRetCond(nz); err != nil {
 			return 1, 8, "RET NZ", err
 		}
@@ -1710,6 +1720,11 @@ func (c *Core) initInstructions() {
 
 		z := c.zeroFlag()
 
+		// the condition check takes a cycle
+		if err := c.tick(4); err != nil {
+			return 1, 8, "RET Z", err
+		}
+
 		if err := c.insRetCond(z); err != nil {
 			return 1, 8, "RET Z", err
 		}
@@ -1726,6 +1741,11 @@ func (c *Core) initInstructions() {
 
 		nc := !c.carryFlag()
 
+		// the condition check takes a cycle
+		if err := c.tick(4); err != nil {
+			return 1, 8, "RET NC", err
+		}
+
 		if err := c.insRetCond(nc); err != nil {
 			return 1, 8, "RET NC", err
 		}
@@ -1742,6 +1762,11 @@ func (c *Core) initInstructions() {
 
 		carry := c.carryFlag()
 
+		// the condition check takes a cycle
+		if err := c.tick(4); err != nil {
+			return 1, 8, "RET C", err
+		}
+
 		if err := c.insRetCond(carry); err != nil {
 			return 1, 8, "RET C", err
 		}
@@ -47,7 +47,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x06] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RLC (HL)", err
@@ -57,7 +57,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insRlcR(&r, true)
 
-		return 2, 16, "RLC (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RLC (HL)", c.write(hl, r.get())
 	}
 
 	// RLC A
@@ -106,7 +106,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x0E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RRC (HL)", err
@@ -116,7 +116,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insRrcR(&r, true)
 
-		return 2, 16, "RRC (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RRC (HL)", c.write(hl, r.get())
 	}
 
 	// RRC A
@@ -165,7 +165,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x16] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RL (HL)", err
@@ -175,7 +175,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insRlR(&r, true)
 
-		return 2, 16, "RL (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RL (HL)", c.write(hl, r.get())
 	}
 
 	// RL A
@@ -224,7 +224,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x1E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RR (HL)", err
@@ -234,7 +234,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insRrR(&r, true)
 
-		return 2, 16, "RR (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RR (HL)", c.write(hl, r.get())
 	}
 
 	// RR A
@@ -283,7 +283,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x26] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SLA (HL)", err
@@ -293,7 +293,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSlaR(&r)
 
-		return 2, 16, "SLA (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SLA (HL)", c.write(hl, r.get())
 	}
 
 	// SLA A
@@ -342,7 +342,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x2E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SRA (HL)", err
@@ -352,7 +352,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSraR(&r)
 
-		return 2, 16, "SRA (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SRA (HL)", c.write(hl, r.get())
 	}
 
 	// SRA A
@@ -401,7 +401,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x36] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SWAP (HL)", err
@@ -411,7 +411,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSwapR(&r)
 
-		return 2, 16, "SWAP (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SWAP (HL)", c.write(hl, r.get())
 	}
 
 	// SWAP A
@@ -460,7 +460,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x3E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SRL (HL)", err
@@ -470,7 +470,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSinsRlR(&r)
 
-		return 2, 16, "SRL (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SRL (HL)", c.write(hl, r.get())
 	}
 
 	// SRL A
@@ -519,17 +519,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x46] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 0, (HL)", err
+			return 2, 12, "BIT 0, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(0, &r)
 
-		return 2, 16, "BIT 0, (HL)", nil
+		return 2, 12, "BIT 0, (HL)", nil
 	}
 
 	// BIT 0, A
@@ -578,17 +578,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x4E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 1, (HL)", err
+			return 2, 12, "BIT 1, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(1, &r)
 
-		return 2, 16, "BIT 1, (HL)", nil
+		return 2, 12, "BIT 1, (HL)", nil
 	}
 
 	// BIT 1, A
@@ -637,17 +637,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x56] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 2, (HL)", err
+			return 2, 12, "BIT 2, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(2, &r)
 
-		return 2, 16, "BIT 2, (HL)", nil
+		return 2, 12, "BIT 2, (HL)", nil
 	}
 
 	// BIT 2, A
@@ -696,17 +696,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x5E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 3, (HL)", err
+			return 2, 12, "BIT 3, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(3, &r)
 
-		return 2, 16, "BIT 3, (HL)", nil
+		return 2, 12, "BIT 3, (HL)", nil
 	}
 
 	// BIT 3, A
@@ -755,17 +755,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x66] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 4, (HL)", err
+			return 2, 12, "BIT 4, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(4, &r)
 
-		return 2, 16, "BIT 4, (HL)", nil
+		return 2, 12, "BIT 4, (HL)", nil
 	}
 
 	// BIT 4, A
@@ -814,17 +814,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x6E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 5, (HL)", err
+			return 2, 12, "BIT 5, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(5, &r)
 
-		return 2, 16, "BIT 5, (HL)", nil
+		return 2, 12, "BIT 5, (HL)", nil
 	}
 
 	// BIT 5, A
@@ -873,17 +873,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x76] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 6, (HL)", err
+			return 2, 12, "BIT 6, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(6, &r)
 
-		return 2, 16, "BIT 6, (HL)", nil
+		return 2, 12, "BIT 6, (HL)", nil
 	}
 
 	// BIT 6, A
@@ -932,17 +932,17 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x7E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
-			return 2, 16, "BIT 7, (HL)", err
+			return 2, 12, "BIT 7, (HL)", err
 		}
 
 		r := Register8{}
 		r.set(v)
 		c.insBitNr(7, &r)
 
-		return 2, 16, "BIT 7, (HL)", nil
+		return 2, 12, "BIT 7, (HL)", nil
 	}
 
 	// BIT 7, A
@@ -991,7 +991,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x86] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 0, (HL)", err
@@ -1001,7 +1001,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(0, &r)
 
-		return 2, 16, "RES 0, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 0, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 0, A
@@ -1050,7 +1050,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x8E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 1, (HL)", err
@@ -1060,7 +1060,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(1, &r)
 
-		return 2, 16, "RES 1, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 1, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 1, A
@@ -1109,7 +1109,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x96] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 2, (HL)", err
@@ -1119,7 +1119,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(2, &r)
 
-		return 2, 16, "RES 2, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 2, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 2, A
@@ -1168,7 +1168,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0x9E] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 3, (HL)", err
@@ -1178,7 +1178,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(3, &r)
 
-		return 2, 16, "RES 3, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 3, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 3, A
@@ -1227,7 +1227,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xA6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 4, (HL)", err
@@ -1237,7 +1237,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(4, &r)
 
-		return 2, 16, "RES 4, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 4, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 4, A
@@ -1286,7 +1286,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xAE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 5, (HL)", err
@@ -1296,7 +1296,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(5, &r)
 
-		return 2, 16, "RES 5, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 5, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 5, A
@@ -1345,7 +1345,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xB6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 6, (HL)", err
@@ -1355,7 +1355,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(6, &r)
 
-		return 2, 16, "RES 6, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 6, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 6, A
@@ -1404,7 +1404,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xBE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "RES 7, (HL)", err
@@ -1414,7 +1414,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insResNr(7, &r)
 
-		return 2, 16, "RES 7, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "RES 7, (HL)", c.write(hl, r.get())
 	}
 
 	// RES 7, A
@@ -1463,7 +1463,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xC6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 0, (HL)", err
@@ -1473,7 +1473,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(0, &r)
 
-		return 2, 16, "SET 0, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 0, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 0, A
@@ -1522,7 +1522,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xCE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 1, (HL)", err
@@ -1532,7 +1532,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(1, &r)
 
-		return 2, 16, "SET 1, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 1, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 1, A
@@ -1581,7 +1581,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xD6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 2, (HL)", err
@@ -1591,7 +1591,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(2, &r)
 
-		return 2, 16, "SET 2, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 2, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 2, A
@@ -1640,7 +1640,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xDE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 3, (HL)", err
@@ -1650,7 +1650,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(3, &r)
 
-		return 2, 16, "SET 3, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 3, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 3, A
@@ -1699,7 +1699,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xE6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 4, (HL)", err
@@ -1709,7 +1709,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(4, &r)
 
-		return 2, 16, "SET 4, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 4, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 4, A
@@ -1758,7 +1758,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xEE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 5, (HL)", err
@@ -1768,7 +1768,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(5, &r)
 
-		return 2, 16, "SET 5, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 5, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 5, A
@@ -1817,7 +1817,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xF6] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 6, (HL)", err
@@ -1827,7 +1827,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(6, &r)
 
-		return 2, 16, "SET 6, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 6, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 6, A
@@ -1876,7 +1876,7 @@ func (c *Core) initInstructionsCB() {
 	c.instructionsCB[0xFE] = func() (int, int, string, error) {
 
 		hl := c.hl.get()
-		v, err := c.mmu.Read(hl)
+		v, err := c.read(hl)
 
 		if err != nil {
 			return 2, 16, "SET 7, (HL)", err
@@ -1886,7 +1886,7 @@ func (c *Core) initInstructionsCB() {
 		r.set(v)
 		c.insSetNr(7, &r)
 
-		return 2, 16, "SET 7, (HL)", c.mmu.Write(hl, r.get())
+		return 2, 16, "SET 7, (HL)", c.write(hl, r.get())
 	}
 
 	// SET 7, A
@@ -136,16 +136,21 @@ func (c *Core) insRlcR(dst *Register8, cb bool) {
 // insRst jumps to the given address
 func (c *Core) insRst(addr uint16) error {
 
+	// internal cycle before the push
+	if err := c.tick(4); err != nil {
+		return err
+	}
+
 	c.pc.increment()
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), c.pc.highByte()); err != nil {
+	if err := c.write(c.sp.get(), c.pc.highByte()); err != nil {
 		return err
 	}
 
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), c.pc.lowByte()); err != nil {
+	if err := c.write(c.sp.get(), c.pc.lowByte()); err != nil {
 		return err
 	}
 
@@ -166,16 +171,21 @@ func (c *Core) insCallCondA16(cond bool) error {
 
 	if cond {
 
+		// internal cycle before the push
+		if err := c.tick(4); err != nil {
+			return err
+		}
+
 		c.pc.increment()
 		c.sp.decrement()
 
-		if err := c.mmu.Write(c.sp.get(), c.pc.highByte()); err != nil {
+		if err := c.write(c.sp.get(), c.pc.highByte()); err != nil {
 			return err
 		}
 
 		c.sp.decrement()
 
-		if err := c.mmu.Write(c.sp.get(), c.pc.lowByte()); err != nil {
+		if err := c.write(c.sp.get(), c.pc.lowByte()); err != nil {
 			return err
 		}
 
@@ -208,7 +218,7 @@ func (c *Core) insRetCond(cond bool) error {
 
 	if cond {
 
-		if v, err := c.mmu.Read(c.sp.get()); err == nil {
+		if v, err := c.read(c.sp.get()); err == nil {
 			c.pc.setLow(v)
 		} else {
 			return err
@@ -216,7 +226,7 @@ func (c *Core) insRetCond(cond bool) error {
 
 		c.sp.increment()
 
-		if v, err := c.mmu.Read(c.sp.get()); err == nil {
+		if v, err := c.read(c.sp.get()); err == nil {
 			c.pc.setHigh(v)
 		} else {
 			return err
@@ -349,7 +359,7 @@ func (c *Core) insAddAn(n byte) {
 // insLdRm loads (addr) to the register
 func (c *Core) insLdRm(dst *Register8, addr uint16) error {
 
-	v, err := c.mmu.Read(addr)
+	v, err := c.read(addr)
 
 	if err != nil {
 		return err
@@ -391,7 +401,7 @@ func (c *Core) insLdRrD16(dst *Register16) error {
 // insPopRr pops a value from the stack into the register
 func (c *Core) insPopRr(dst *Register16) error {
 
-	if v, err := c.mmu.Read(c.sp.get()); err == nil {
+	if v, err := c.read(c.sp.get()); err == nil {
 		dst.setLow(v)
 	} else {
 		return err
@@ -399,7 +409,7 @@ func (c *Core) insPopRr(dst *Register16) error {
 
 	c.sp.increment()
 
-	if v, err := c.mmu.Read(c.sp.get()); err == nil {
+	if v, err := c.read(c.sp.get()); err == nil {
 		dst.setHigh(v)
 	} else {
 		return err
@@ -413,15 +423,20 @@ func (c *Core) insPopRr(dst *Register16) error {
 // insPushRr pushes the register to the stack
 func (c *Core) insPushRr(src *Register16) error {
 
+	// internal cycle before the push
+	if err := c.tick(4); err != nil {
+		return err
+	}
+
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), src.highByte()); err != nil {
+	if err := c.write(c.sp.get(), src.highByte()); err != nil {
 		return err
 	}
 
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), src.lowByte()); err != nil {
+	if err := c.write(c.sp.get(), src.lowByte()); err != nil {
 		return err
 	}
 
@@ -100,24 +100,30 @@ func (c *Core) handleInterrupts() error {
 }
 
 // jumpToISR disables the IME, saves the current PC and
-// jumps to the given ISR address
+// jumps to the given ISR address (takes 20 cycles)
 func (c *Core) jumpToISR(addr uint16) error {
 
 	c.ime = false
 
+	// two internal cycles before the push
+	if err := c.tick(8); err != nil {
+		return err
+	}
+
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), c.pc.highByte()); err != nil {
+	if err := c.write(c.sp.get(), c.pc.highByte()); err != nil {
 		return err
 	}
 
 	c.sp.decrement()
 
-	if err := c.mmu.Write(c.sp.get(), c.pc.lowByte()); err != nil {
+	if err := c.write(c.sp.get(), c.pc.lowByte()); err != nil {
 		return err
 	}
 
 	c.pc.set(addr)
 
-	return nil
+	// and one after it
+	return c.tick(4)
 }
@@ -0,0 +1,128 @@
+package cpu
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// FrameCycles is the number of cpu cycles in a single frame
+const FrameCycles int = 70224
+
+// maxLag is how far the execution can fall behind the frame
+// deadlines (e.g. after a pause) before the deadlines are reset
+const maxLag = 250 * time.Millisecond
+
+// scheduler paces the execution, the core runs as fast as possible for
+// a frame's worth of cycles and then sleeps until the frame's deadline
+type scheduler struct {
+	speed    uint64 // math.Float64bits of the speed multiplier
+	fps      uint32
+	cycles   int       // cycles since the last frame
+	deadline time.Time // end of the current frame
+	drift    func() time.Duration
+	onFrame  func()
+	onPause  func()
+}
+
+// SetSpeed sets the speed multiplier (1 for the original speed,
+// 0 to run as fast as possible), safe to call from any goroutine
+func (c *Core) SetSpeed(multiplier float64) {
+
+	if multiplier < 0 {
+		multiplier = 0
+	}
+
+	atomic.StoreUint64(&c.sched.speed, math.Float64bits(multiplier))
+}
+
+// Speed returns the speed multiplier
+func (c *Core) Speed() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.sched.speed))
+}
+
+// SetFrameRate sets the number of frames per second at the original speed
+func (c *Core) SetFrameRate(fps uint32) {
+
+	if fps == 0 {
+		fps = 1
+	}
+
+	c.sched.fps = fps
+}
+
+// SetDriftCorrection sets the function that returns how far the execution
+// is ahead of real time (negative when behind), e.g. the length of the
+// queued audio beyond its target, the frame deadlines are adjusted to it
+// while running at the original speed
+func (c *Core) SetDriftCorrection(drift func() time.Duration) {
+	c.sched.drift = drift
+}
+
+// SetFrameHook sets a function that is called at the end of every
+// frame, between two instructions (nil to remove)
+func (c *Core) SetFrameHook(fn func()) {
+	c.sched.onFrame = fn
+}
+
+// SetPauseHook sets a function that is called repeatedly while the
+// core is paused, between two instructions (nil to remove)
+func (c *Core) SetPauseHook(fn func()) {
+	c.sched.onPause = fn
+}
+
+// pace sleeps until the current frame's deadline, once a
+// frame's worth of cycles was executed
+func (c *Core) pace() {
+
+	if c.sched.cycles < FrameCycles {
+		return
+	}
+
+	c.sched.cycles -= FrameCycles
+
+	// a single frame was requested
+	if atomic.CompareAndSwapInt32(&c.advance, 1, 0) {
+		atomic.StoreInt32(&c.pause, 1)
+	}
+
+	if c.sched.onFrame != nil {
+		c.sched.onFrame()
+	}
+
+	speed := c.Speed()
+
+	if speed == 0 {
+		c.sched.deadline = time.Time{}
+		return
+	}
+
+	period := time.Duration(float64(time.Second) / (float64(c.sched.fps) * speed))
+	now := time.Now()
+
+	// start over when too far behind
+	if c.sched.deadline.IsZero() || now.Sub(c.sched.deadline) > maxLag {
+		c.sched.deadline = now
+	}
+
+	c.sched.deadline = c.sched.deadline.Add(period)
+
+	if speed == 1 && c.sched.drift != nil {
+
+		// move the deadline gradually, up to an eighth of a frame
+		correction := c.sched.drift() / 16
+		limit := period / 8
+
+		if correction > limit {
+			correction = limit
+		} else if correction < -limit {
+			correction = -limit
+		}
+
+		c.sched.deadline = c.sched.deadline.Add(correction)
+	}
+
+	if d := time.Until(c.sched.deadline); d > 0 {
+		time.Sleep(d)
+	}
+}
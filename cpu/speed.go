@@ -0,0 +1,68 @@
+package cpu
+
+import "github.com/moshenahmias/gopherboy/memory"
+
+// AddrKEY1 is the address of the speed switch register (cgb)
+const AddrKEY1 uint16 = 0xFF4D
+
+// speedSwitch is the KEY1 register, bit 0 prepares a speed switch that
+// takes place on the next STOP instruction, bit 7 is the current speed
+type speedSwitch struct {
+	double   bool
+	prepared bool
+}
+
+// Read from the register
+func (s *speedSwitch) Read(addr uint16) (byte, error) {
+
+	var data byte
+
+	if s.double {
+		data |= 0x80
+	}
+
+	if s.prepared {
+		data |= 0x01
+	}
+
+	return data, nil
+}
+
+// Write to the register
+func (s *speedSwitch) Write(addr uint16, data byte) error {
+	s.prepared = data&0x01 == 0x01
+	return nil
+}
+
+// EnableSpeedSwitch maps the KEY1 register (cgb mode), the cpu and
+// the units registered with RegisterToClockChanges run twice as fast
+// after a switch to double speed
+func (c *Core) EnableSpeedSwitch(mmu *memory.MMU) error {
+	return mmu.Map(&c.speed, AddrKEY1, AddrKEY1)
+}
+
+// DoubleSpeed returns true in double speed mode
+func (c *Core) DoubleSpeed() bool {
+	return c.speed.double
+}
+
+// RegisterToFixedClockChanges registers a unit that runs at the
+// normal speed in double speed mode too (e.g. the gpu and apu),
+// it is clocked with the number of normal speed cycles
+func (c *Core) RegisterToFixedClockChanges(unit TimedUnit) {
+	c.fixedUnits = append(c.fixedUnits, unit)
+}
+
+// switchSpeed switches the speed if a switch was prepared,
+// returns true if the speed was switched
+func (c *Core) switchSpeed() bool {
+
+	if !c.speed.prepared {
+		return false
+	}
+
+	c.speed.prepared = false
+	c.speed.double = !c.speed.double
+
+	return true
+}
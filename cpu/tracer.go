@@ -0,0 +1,165 @@
+package cpu
+
+import (
+	"bufio"
+	"io"
+	"sync/atomic"
+)
+
+const hexDigits = "0123456789ABCDEF"
+
+// Tracer writes a line per executed instruction, in the gameboy-doctor
+// log format ("A:00 F:00 ... SP:0000 PC:0000 PCMEM:00,00,00,00")
+type Tracer struct {
+	w       *bufio.Writer
+	enabled int32      // toggled at runtime
+	from    uint16     // pc range start
+	to      uint16     // pc range end
+	bank    int        // rom bank filter (-1 for any bank)
+	romBank func() int // returns the rom bank mapped to 4000-7FFF
+	doctor  bool       // ly is stubbed to $90
+	line    []byte
+}
+
+// NewTracer creates an enabled Tracer instance that writes to 'w'
+func NewTracer(w io.Writer) *Tracer {
+
+	return &Tracer{
+		w:       bufio.NewWriterSize(w, 1<<16),
+		enabled: 1,
+		to:      0xFFFF,
+		bank:    -1}
+}
+
+// SetRange traces only the instructions in the address range 'from -> to'
+func (t *Tracer) SetRange(from, to uint16) {
+	t.from = from
+	t.to = to
+}
+
+// SetBank traces only the instructions in rom bank 'bank' (-1 for any bank)
+func (t *Tracer) SetBank(bank int) {
+	t.bank = bank
+}
+
+// SetDoctor stubs the LY register to $90 (as gameboy-doctor expects),
+// so the log matches the doctor's reference logs, must be set before
+// the tracer is attached to the gameboy
+func (t *Tracer) SetDoctor(on bool) {
+	t.doctor = on
+}
+
+// Doctor returns true if LY should be stubbed to $90
+func (t *Tracer) Doctor() bool {
+	return t.doctor
+}
+
+// SetROMBank sets the function that returns the
+// rom bank that is mapped to 4000-7FFF
+func (t *Tracer) SetROMBank(romBank func() int) {
+	t.romBank = romBank
+}
+
+// Enable (or disable) the tracing, safe to call from any goroutine
+func (t *Tracer) Enable(on bool) {
+
+	if on {
+		atomic.StoreInt32(&t.enabled, 1)
+	} else {
+		atomic.StoreInt32(&t.enabled, 0)
+	}
+}
+
+// Enabled returns true if the tracing is enabled
+func (t *Tracer) Enabled() bool {
+	return atomic.LoadInt32(&t.enabled) != 0
+}
+
+// Toggle the tracing, returns the new state
+func (t *Tracer) Toggle() bool {
+
+	on := !t.Enabled()
+	t.Enable(on)
+
+	return on
+}
+
+// Flush the buffered lines
+func (t *Tracer) Flush() error {
+	return t.w.Flush()
+}
+
+// SetTracer sets the tracer that is called before every
+// instruction execution (nil to remove)
+func (c *Core) SetTracer(t *Tracer) {
+	c.tracer = t
+}
+
+// trace the instruction at 'pc'
+func (t *Tracer) trace(c *Core, pc uint16) {
+
+	if atomic.LoadInt32(&t.enabled) == 0 || pc < t.from || pc > t.to {
+		return
+	}
+
+	if t.bank >= 0 {
+
+		switch {
+		case pc <= 0x3FFF:
+			if t.bank != 0 {
+				return
+			}
+		case pc <= 0x7FFF && t.romBank != nil:
+			if t.bank != t.romBank() {
+				return
+			}
+		default:
+			return
+		}
+	}
+
+	l := t.line[:0]
+
+	l = appendReg8(l, "A:", c.a.get())
+	l = appendReg8(l, " F:", c.f.get())
+	l = appendReg8(l, " B:", c.b.get())
+	l = appendReg8(l, " C:", c.c.get())
+	l = appendReg8(l, " D:", c.d.get())
+	l = appendReg8(l, " E:", c.e.get())
+	l = appendReg8(l, " H:", c.h.get())
+	l = appendReg8(l, " L:", c.l.get())
+	l = appendReg16(l, " SP:", c.sp.get())
+	l = appendReg16(l, " PC:", pc)
+	l = append(l, " PCMEM:"...)
+
+	for i := uint16(0); i < 4; i++ {
+
+		if i > 0 {
+			l = append(l, ',')
+		}
+
+		var data byte
+
+		// avoid triggering watchpoints
+		data, _ = c.mmu.Peek(pc + i)
+
+		l = append(l, hexDigits[data>>4], hexDigits[data&0x0F])
+	}
+
+	l = append(l, '\n')
+
+	t.w.Write(l)
+	t.line = l
+}
+
+// appendReg8 appends "<name><2 hex digits>"
+func appendReg8(l []byte, name string, v byte) []byte {
+	l = append(l, name...)
+	return append(l, hexDigits[v>>4], hexDigits[v&0x0F])
+}
+
+// appendReg16 appends "<name><4 hex digits>"
+func appendReg16(l []byte, name string, v uint16) []byte {
+	l = appendReg8(l, name, byte(v>>8))
+	return append(l, hexDigits[(v>>4)&0x0F], hexDigits[v&0x0F])
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/gameboy"
+)
+
+const debuggerHelp = `all numbers are hex
+  step (s)                       execute a single instruction
+  next (n)                       execute a single instruction, step over calls
+  continue (c)                   run until a breakpoint / watchpoint (ctrl+c breaks)
+  until (u) <addr>               run until the pc reaches addr
+  break (b) <addr> [if <cond>]   set a breakpoint, e.g. "b 0150 if a == 10 && zf == 1"
+  delete (d) <addr>              delete a breakpoint
+  watch (w) <from>[-<to>] [r|w|rw] set a memory watchpoint (default: w)
+  unwatch <from>                 delete a watchpoint
+  list (l)                       list the breakpoints and watchpoints
+  regs (r)                       print the registers and flags
+  set <reg> <value>              set a register (a, f, ..., af, ..., sp, pc) or a flag (zf, nf, hf, cf)
+  x <addr> [count]               print memory
+  dis [addr] [count]             disassemble (default: from pc)
+  quit (q)                       quit the emulator
+  (empty line repeats the last step / next)`
+
+// errUnknownCommand is returned for an unknown debugger command
+var errUnknownCommand = errors.New("unknown command (type 'help' for the commands list)")
+
+// repl is a terminal front end for the cpu debugger
+type repl struct {
+	lines    chan string
+	out      io.Writer
+	quit     func()
+	last     string
+	m        sync.Mutex
+	debugger *cpu.Debugger
+}
+
+// newREPL creates repl instance, 'quit' is called
+// when the user quits the emulator
+func newREPL(in io.Reader, out io.Writer, quit func()) *repl {
+
+	r := &repl{lines: make(chan string), out: out, quit: quit}
+
+	// read commands
+	go func() {
+
+		scanner := bufio.NewScanner(in)
+
+		for scanner.Scan() {
+			r.lines <- scanner.Text()
+		}
+
+		close(r.lines)
+	}()
+
+	// break on ctrl+c
+	go func() {
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+
+		for range sig {
+
+			r.m.Lock()
+
+			if r.debugger != nil {
+				r.debugger.Break()
+			}
+
+			r.m.Unlock()
+		}
+	}()
+
+	return r
+}
+
+// attach a debugger to the gameboy
+func (r *repl) attach(gb *gameboy.Gameboy) {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.debugger = cpu.NewDebugger(gb.Core(), gb.MMU(), r.handle)
+}
+
+// handle a break, reads and executes commands until the execution resumes
+func (r *repl) handle(d *cpu.Debugger, reason string) error {
+
+	fmt.Fprintln(r.out, reason)
+	r.printLocation(d)
+
+	for !d.Stopped() {
+
+		fmt.Fprint(r.out, "(gb) ")
+
+		line, ok := r.readLine(d)
+
+		if !ok {
+
+			// stdin closed
+			if !d.Stopped() {
+				d.Stop()
+				r.quit()
+			}
+
+			return nil
+		}
+
+		if len(strings.TrimSpace(line)) == 0 {
+			line = r.last
+		}
+
+		resume, err := r.exec(d, line)
+
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+		}
+
+		if resume {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// readLine waits for a command (while the emulator is running)
+func (r *repl) readLine(d *cpu.Debugger) (string, bool) {
+
+	for {
+
+		select {
+
+		case line, ok := <-r.lines:
+
+			return line, ok
+
+		case <-time.After(time.Millisecond * 100):
+
+			if d.Stopped() {
+				return "", false
+			}
+		}
+	}
+}
+
+// exec a single command, returns true if the execution should resume
+func (r *repl) exec(d *cpu.Debugger, line string) (bool, error) {
+
+	args := strings.Fields(line)
+
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+
+	case "s", "step":
+
+		r.last = args[0]
+		d.Step()
+
+		return true, nil
+
+	case "n", "next":
+
+		r.last = args[0]
+
+		if err := d.StepOver(); err != nil {
+			return false, err
+		}
+
+		return true, nil
+
+	case "c", "continue":
+
+		d.Continue()
+
+		return true, nil
+
+	case "u", "until":
+
+		if len(args) != 2 {
+			return false, errUnknownCommand
+		}
+
+		addr, err := cpu.ParseHex(args[1])
+
+		if err != nil {
+			return false, err
+		}
+
+		d.RunTo(addr)
+
+		return true, nil
+
+	case "b", "break":
+
+		if len(args) < 2 || (len(args) > 2 && args[2] != "if") {
+			return false, errUnknownCommand
+		}
+
+		addr, err := cpu.ParseHex(args[1])
+
+		if err != nil {
+			return false, err
+		}
+
+		var condition string
+
+		if len(args) > 3 {
+			condition = strings.Join(args[3:], " ")
+		}
+
+		return false, d.SetBreakpoint(addr, condition)
+
+	case "d", "delete":
+
+		if len(args) != 2 {
+			return false, errUnknownCommand
+		}
+
+		addr, err := cpu.ParseHex(args[1])
+
+		if err != nil {
+			return false, err
+		}
+
+		if !d.ClearBreakpoint(addr) {
+			return false, fmt.Errorf("no breakpoint at %04x", addr)
+		}
+
+	case "w", "watch":
+
+		return false, r.watch(d, args[1:])
+
+	case "unwatch":
+
+		if len(args) != 2 {
+			return false, errUnknownCommand
+		}
+
+		addr, err := cpu.ParseHex(args[1])
+
+		if err != nil {
+			return false, err
+		}
+
+		if !d.Unwatch(addr) {
+			return false, fmt.Errorf("no watchpoint at %04x", addr)
+		}
+
+	case "l", "list":
+
+		for _, bp := range d.Breakpoints() {
+			if len(bp.Condition) > 0 {
+				fmt.Fprintf(r.out, "break %04x if %s\n", bp.Addr, bp.Condition)
+			} else {
+				fmt.Fprintf(r.out, "break %04x\n", bp.Addr)
+			}
+		}
+
+		for _, wp := range d.Watchpoints() {
+
+			var mode string
+
+			if wp.Read {
+				mode += "r"
+			}
+
+			if wp.Write {
+				mode += "w"
+			}
+
+			fmt.Fprintf(r.out, "watch %04x-%04x %s\n", wp.From, wp.To, mode)
+		}
+
+	case "r", "regs":
+
+		regs := d.Registers()
+		fmt.Fprintln(r.out, regs.String())
+
+	case "set":
+
+		if len(args) != 3 {
+			return false, errUnknownCommand
+		}
+
+		value, err := cpu.ParseHex(args[2])
+
+		if err != nil {
+			return false, err
+		}
+
+		return false, d.SetRegister(args[1], value)
+
+	case "x":
+
+		return false, r.dump(d, args[1:])
+
+	case "dis":
+
+		return false, r.disassemble(d, args[1:])
+
+	case "q", "quit":
+
+		d.Stop()
+		r.quit()
+
+		return true, nil
+
+	case "h", "help":
+
+		fmt.Fprintln(r.out, debuggerHelp)
+
+	default:
+
+		return false, errUnknownCommand
+	}
+
+	return false, nil
+}
+
+// watch parses and sets a watchpoint
+func (r *repl) watch(d *cpu.Debugger, args []string) error {
+
+	if len(args) == 0 || len(args) > 2 {
+		return errUnknownCommand
+	}
+
+	from, to, err := cpu.ParseHexRange(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	mode := "w"
+
+	if len(args) == 2 {
+		mode = args[1]
+	}
+
+	switch mode {
+	case "r":
+		return d.Watch(from, to, true, false)
+	case "w":
+		return d.Watch(from, to, false, true)
+	case "rw":
+		return d.Watch(from, to, true, true)
+	}
+
+	return errUnknownCommand
+}
+
+// dump prints memory, 16 bytes per line
+func (r *repl) dump(d *cpu.Debugger, args []string) error {
+
+	if len(args) == 0 || len(args) > 2 {
+		return errUnknownCommand
+	}
+
+	addr, err := cpu.ParseHex(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	count := uint64(16)
+
+	if len(args) == 2 {
+
+		if count, err = strconv.ParseUint(args[1], 16, 16); err != nil {
+			return err
+		}
+	}
+
+	for i := uint64(0); i < count; i++ {
+
+		if i%16 == 0 {
+
+			if i > 0 {
+				fmt.Fprintln(r.out)
+			}
+
+			fmt.Fprintf(r.out, "%04x:", addr)
+		}
+
+		if data, err := d.Peek(addr); err != nil {
+			fmt.Fprint(r.out, " ??")
+		} else {
+			fmt.Fprintf(r.out, " %02x", data)
+		}
+
+		addr++
+	}
+
+	fmt.Fprintln(r.out)
+
+	return nil
+}
+
+// disassemble prints 'count' instructions
+func (r *repl) disassemble(d *cpu.Debugger, args []string) error {
+
+	if len(args) > 2 {
+		return errUnknownCommand
+	}
+
+	var err error
+
+	addr := d.Registers().PC
+	count := uint64(8)
+
+	if len(args) > 0 {
+
+		if addr, err = cpu.ParseHex(args[0]); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 1 {
+
+		if count, err = strconv.ParseUint(args[1], 16, 16); err != nil {
+			return err
+		}
+	}
+
+	for i := uint64(0); i < count; i++ {
+		addr += r.printInstruction(d, addr)
+	}
+
+	return nil
+}
+
+// printInstruction prints the instruction at 'addr', returns its length
+func (r *repl) printInstruction(d *cpu.Debugger, addr uint16) uint16 {
+
+	text, n := d.Disassemble(addr)
+
+	var hex string
+
+	for i := 0; i < n; i++ {
+		if data, err := d.Peek(addr + uint16(i)); err == nil {
+			hex += fmt.Sprintf(" %02x", data)
+		}
+	}
+
+	fmt.Fprintf(r.out, "%04x:%-9s  %s\n", addr, hex, text)
+
+	return uint16(n)
+}
+
+// printLocation prints the registers and the instruction at pc
+func (r *repl) printLocation(d *cpu.Debugger) {
+
+	regs := d.Registers()
+
+	fmt.Fprintln(r.out, regs.String())
+	r.printInstruction(d, regs.PC)
+}
@@ -0,0 +1,22 @@
+package display
+
+// BorderWidth is the width of the super game boy screen
+const BorderWidth int = 256
+
+// BorderHeight is the height of the super game boy screen
+const BorderHeight int = 224
+
+// BorderFrameX is the x position of the frame in the border
+const BorderFrameX int = 48
+
+// BorderFrameY is the y position of the frame in the border
+const BorderFrameY int = 40
+
+// Border is a 256x224 matrix that represents the super game boy
+// border, the frames are drawn over its center
+type Border [BorderWidth][BorderHeight]Pixel
+
+// BorderDrawer is a monitor that can draw a border around the frames
+type BorderDrawer interface {
+	DrawBorder(b *Border) error
+}
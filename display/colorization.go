@@ -0,0 +1,210 @@
+package display
+
+import (
+	"strings"
+
+	"github.com/moshenahmias/gopherboy/game"
+)
+
+// DMGPalettes are the colors of a monochrome game on the cgb, a separate
+// 4 color palette for the background and for each sprite palette
+type DMGPalettes struct {
+	BG  [4]Pixel
+	OBJ [2][4]Pixel
+}
+
+// dmgColors are the RGB555 colors of the cgb boot rom's palettes (4 per palette)
+var dmgColors = [...]uint16{
+	0x7FFF, 0x32BF, 0x00D0, 0x0000, // 0
+	0x639F, 0x4279, 0x15B0, 0x04CB, // 1
+	0x7FFF, 0x6E31, 0x454A, 0x0000, // 2
+	0x7FFF, 0x1BEF, 0x0200, 0x0000, // 3
+	0x7FFF, 0x421F, 0x1CF2, 0x0000, // 4
+	0x7FFF, 0x5294, 0x294A, 0x0000, // 5
+	0x7FFF, 0x03FF, 0x012F, 0x0000, // 6
+	0x7FFF, 0x03EF, 0x01D6, 0x0000, // 7
+	0x7FFF, 0x42B5, 0x3DC8, 0x0000, // 8
+	0x7E74, 0x03FF, 0x0180, 0x0000, // 9
+	0x67FF, 0x77AC, 0x1A13, 0x2D6B, // 10
+	0x7ED6, 0x4BFF, 0x2175, 0x0000, // 11
+	0x53FF, 0x4A5F, 0x7E52, 0x0000, // 12
+	0x4FFF, 0x7ED2, 0x3A4C, 0x1CE0, // 13
+	0x03ED, 0x7FFF, 0x255F, 0x0000, // 14
+	0x036A, 0x021F, 0x03FF, 0x7FFF, // 15
+	0x7FFF, 0x01DF, 0x0112, 0x0000, // 16
+	0x231F, 0x035F, 0x00F2, 0x0009, // 17
+	0x7FFF, 0x03EA, 0x011F, 0x0000, // 18
+	0x299F, 0x001A, 0x000C, 0x0000, // 19
+	0x7FFF, 0x027F, 0x001F, 0x0000, // 20
+	0x7FFF, 0x03E0, 0x0206, 0x0120, // 21
+	0x7FFF, 0x7EEB, 0x001F, 0x7C00, // 22
+	0x7FFF, 0x3FFF, 0x7E00, 0x001F, // 23
+	0x7FFF, 0x03FF, 0x001F, 0x0000, // 24
+	0x03FF, 0x001F, 0x000C, 0x0000, // 25
+	0x7FFF, 0x033F, 0x0193, 0x0000, // 26
+	0x0000, 0x4200, 0x037F, 0x7FFF, // 27
+	0x7FFF, 0x7E8C, 0x7C00, 0x0000, // 28
+	0x7FFF, 0x1BEF, 0x6180, 0x0000} // 29
+
+// dmgCombos are the palette combinations of the cgb boot rom, the
+// offsets (in colors) of the obj0, obj1 and bg palettes in dmgColors,
+// a few combinations start in the middle of a palette
+var dmgCombos = [...][3]int{
+	{4 * 4, 4 * 4, 29 * 4},     // 0 (the default)
+	{18 * 4, 18 * 4, 18 * 4},   // 1
+	{20 * 4, 20 * 4, 20 * 4},   // 2
+	{24 * 4, 24 * 4, 24 * 4},   // 3
+	{9 * 4, 9 * 4, 9 * 4},      // 4
+	{0 * 4, 0 * 4, 0 * 4},      // 5
+	{27 * 4, 27 * 4, 27 * 4},   // 6
+	{5 * 4, 5 * 4, 5 * 4},      // 7
+	{12 * 4, 12 * 4, 12 * 4},   // 8
+	{26 * 4, 26 * 4, 26 * 4},   // 9
+	{16 * 4, 8 * 4, 8 * 4},     // 10
+	{4 * 4, 28 * 4, 28 * 4},    // 11
+	{4 * 4, 2 * 4, 2 * 4},      // 12
+	{3 * 4, 4 * 4, 4 * 4},      // 13
+	{4 * 4, 29 * 4, 29 * 4},    // 14
+	{28 * 4, 4 * 4, 28 * 4},    // 15
+	{2 * 4, 17 * 4, 2 * 4},     // 16
+	{16 * 4, 16 * 4, 8 * 4},    // 17
+	{4 * 4, 4 * 4, 7 * 4},      // 18
+	{4 * 4, 4 * 4, 18 * 4},     // 19
+	{4 * 4, 4 * 4, 20 * 4},     // 20
+	{19 * 4, 19 * 4, 9 * 4},    // 21
+	{4*4 - 1, 4*4 - 1, 11 * 4}, // 22
+	{17 * 4, 17 * 4, 2 * 4},    // 23
+	{4 * 4, 4 * 4, 2 * 4},      // 24
+	{4 * 4, 4 * 4, 3 * 4},      // 25
+	{28 * 4, 28 * 4, 0 * 4},    // 26
+	{3 * 4, 3 * 4, 0 * 4},      // 27
+	{0 * 4, 0 * 4, 1 * 4},      // 28
+	{18 * 4, 22 * 4, 18 * 4},   // 29
+	{20 * 4, 22 * 4, 20 * 4},   // 30
+	{24 * 4, 22 * 4, 24 * 4},   // 31
+	{16 * 4, 22 * 4, 8 * 4},    // 32
+	{17 * 4, 4 * 4, 13 * 4},    // 33
+	{28*4 - 1, 0 * 4, 14 * 4},  // 34
+	{28*4 - 1, 4 * 4, 15 * 4},  // 35
+	{19 * 4, 22 * 4, 9 * 4},    // 36
+	{16 * 4, 28 * 4, 10 * 4},   // 37
+	{4 * 4, 23 * 4, 28 * 4},    // 38
+	{17 * 4, 22 * 4, 2 * 4},    // 39
+	{4 * 4, 0 * 4, 2 * 4},      // 40
+	{4 * 4, 28 * 4, 3 * 4},     // 41
+	{28 * 4, 3 * 4, 0 * 4},     // 42
+	{3 * 4, 28 * 4, 4 * 4},     // 43
+	{21 * 4, 28 * 4, 4 * 4},    // 44
+	{3 * 4, 28 * 4, 0 * 4},     // 45
+	{25 * 4, 3 * 4, 28 * 4},    // 46
+	{0 * 4, 28 * 4, 8 * 4},     // 47
+	{4 * 4, 3 * 4, 28 * 4},     // 48
+	{28 * 4, 3 * 4, 6 * 4},     // 49
+	{4 * 4, 28 * 4, 29 * 4}}    // 50
+
+// dmgTitleChecksums are the title checksums of the games the cgb boot
+// rom colorizes, the checksums from index dmgFirstDuplicate on are shared
+// by several games and are told apart by the 4th title letter
+var dmgTitleChecksums = [...]byte{
+	0x88, 0x16, 0x36, 0xD1, 0xDB, 0xF2, 0x3C, 0x8C, 0x92, 0x3D, 0x5C, 0x58, 0xC9, 0x3E, 0x70, 0x1D,
+	0x59, 0x69, 0x19, 0x35, 0xA8, 0x14, 0xAA, 0x75, 0x95, 0x99, 0x34, 0x6F, 0x15, 0xFF, 0x97, 0x4B,
+	0x90, 0x17, 0x10, 0x39, 0xF7, 0xF6, 0xA2, 0x49, 0x4E, 0x43, 0x68, 0xE0, 0x8B, 0xF0, 0xCE, 0x0C,
+	0x29, 0xE8, 0xB7, 0x86, 0x9A, 0x52, 0x01, 0x9D, 0x71, 0x9C, 0xBD, 0x5D, 0x6D, 0x67, 0x3F, 0x6B,
+
+	// duplicates
+	0xB3, 0x46, 0x28, 0xA5, 0xC6, 0xD3, 0x27, 0x61, 0x18, 0x66, 0x6A, 0xBF, 0x0D, 0xF4,
+	0xB3, 0x46, 0x28, 0xA5, 0xC6, 0xD3, 0x27, 0x61, 0x18, 0x66, 0x6A, 0xBF, 0x0D, 0xF4,
+	0xB3}
+
+// dmgFirstDuplicate is the index of the first shared checksum
+const dmgFirstDuplicate = 64
+
+// dmgDuplicateLetters are the 4th title letters of the shared checksums
+const dmgDuplicateLetters = "BEFAARBEKEK R-URAR INAILICE R"
+
+// dmgChecksumCombos are the palette combinations of dmgTitleChecksums
+var dmgChecksumCombos = [...]byte{
+	4, 5, 35, 34, 3, 31, 15, 10, 5, 19, 36, 7, 37, 30, 44, 21,
+	32, 31, 20, 5, 33, 13, 14, 5, 29, 5, 18, 9, 3, 2, 26, 25,
+	25, 41, 42, 26, 45, 42, 45, 36, 38, 26, 42, 30, 41, 34, 34, 5,
+	42, 6, 5, 33, 25, 42, 42, 40, 2, 16, 25, 42, 42, 5, 0, 39,
+
+	// duplicates
+	36, 22, 25, 6, 32, 12, 36, 11, 39, 18, 39, 24, 31, 50,
+	17, 46, 6, 27, 0, 47, 41, 41, 0, 0, 19, 34, 23, 18,
+	29}
+
+// dmgManualCombos are the palette combinations (indexes in dmgCombos) that
+// are selected by holding a direction (and optionally A or B) while the
+// cgb boot logo is shown
+var dmgManualCombos = map[string]int{
+	"up":      5,
+	"up+a":    43,
+	"up+b":    28,
+	"left":    48,
+	"left+a":  40,
+	"left+b":  7,
+	"down":    8,
+	"down+a":  3,
+	"down+b":  49,
+	"right":   1,
+	"right+a": 0,
+	"right+b": 6}
+
+// newDMGPalettes creates DMGPalettes instance from a palette combination
+func newDMGPalettes(combo [3]int) *DMGPalettes {
+
+	var p DMGPalettes
+
+	for i := 0; i < 4; i++ {
+		p.OBJ[0][i] = RGB555(dmgColors[combo[0]+i])
+		p.OBJ[1][i] = RGB555(dmgColors[combo[1]+i])
+		p.BG[i] = RGB555(dmgColors[combo[2]+i])
+	}
+
+	return &p
+}
+
+// AutoDMGPalettes returns the palettes that the cgb boot rom selects for a
+// monochrome game, by the title checksum (and the 4th title letter), games
+// of other licensees than nintendo get the default palettes
+func AutoDMGPalettes(h *game.Header) *DMGPalettes {
+
+	if h.OldLicenseeCode != 0x01 && !(h.OldLicenseeCode == 0x33 && h.NewLicenseeCode == "01") {
+		return newDMGPalettes(dmgCombos[0])
+	}
+
+	var checksum byte
+
+	for _, b := range h.TitleBytes {
+		checksum += b
+	}
+
+	for i, c := range dmgTitleChecksums {
+
+		if c != checksum {
+			continue
+		}
+
+		if i >= dmgFirstDuplicate && dmgDuplicateLetters[i-dmgFirstDuplicate] != h.TitleBytes[3] {
+			continue
+		}
+
+		return newDMGPalettes(dmgCombos[dmgChecksumCombos[i]])
+	}
+
+	return newDMGPalettes(dmgCombos[0])
+}
+
+// ManualDMGPalettes returns the palettes of a boot button combination
+// (e.g. "left+a"), false if there is no such combination
+func ManualDMGPalettes(combo string) (*DMGPalettes, bool) {
+
+	c, found := dmgManualCombos[strings.ToLower(combo)]
+
+	if !found {
+		return nil, false
+	}
+
+	return newDMGPalettes(dmgCombos[c]), true
+}
@@ -0,0 +1,57 @@
+package display
+
+import "testing"
+
+// TestManualDMGPalettes checks the palettes of the boot button combinations
+// against the combinations of the cgb boot rom
+func TestManualDMGPalettes(t *testing.T) {
+
+	tests := []struct {
+		combo string
+		bg    uint16 // the 2nd bg color
+		obj0  uint16 // the 2nd obj0 color
+		obj1  uint16 // the 2nd obj1 color
+	}{
+		{"up", 0x32BF, 0x32BF, 0x32BF},
+		{"up+a", 0x421F, 0x1BEF, 0x7E8C},
+		{"up+b", 0x4279, 0x32BF, 0x32BF},
+		{"left", 0x7E8C, 0x421F, 0x1BEF},
+		{"left+a", 0x6E31, 0x421F, 0x32BF},
+		{"left+b", 0x5294, 0x5294, 0x5294},
+		{"down", 0x4A5F, 0x4A5F, 0x4A5F},
+		{"down+a", 0x03FF, 0x03FF, 0x03FF},
+		{"down+b", 0x03FF, 0x7E8C, 0x1BEF},
+		{"right", 0x03EA, 0x03EA, 0x03EA},
+		{"right+a", 0x1BEF, 0x421F, 0x421F},
+		{"right+b", 0x4200, 0x4200, 0x4200},
+		{"Right+B", 0x4200, 0x4200, 0x4200},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.combo, func(t *testing.T) {
+
+			p, found := ManualDMGPalettes(test.combo)
+
+			if !found {
+				t.Fatalf("%s not found", test.combo)
+			}
+
+			if p.BG[1] != RGB555(test.bg) {
+				t.Errorf("bg = %04X, want %04X", p.BG[1], RGB555(test.bg))
+			}
+
+			if p.OBJ[0][1] != RGB555(test.obj0) {
+				t.Errorf("obj0 = %04X, want %04X", p.OBJ[0][1], RGB555(test.obj0))
+			}
+
+			if p.OBJ[1][1] != RGB555(test.obj1) {
+				t.Errorf("obj1 = %04X, want %04X", p.OBJ[1][1], RGB555(test.obj1))
+			}
+		})
+	}
+
+	if _, found := ManualDMGPalettes("up+start"); found {
+		t.Error("up+start found")
+	}
+}
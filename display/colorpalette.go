@@ -0,0 +1,67 @@
+package display
+
+// AddrBCPS is the Background Color Palette Specification register address (cgb)
+const AddrBCPS uint16 = 0xFF68
+
+// AddrBCPD is the Background Color Palette Data register address (cgb)
+const AddrBCPD uint16 = 0xFF69
+
+// AddrOCPS is the Object Color Palette Specification register address (cgb)
+const AddrOCPS uint16 = 0xFF6A
+
+// AddrOCPD is the Object Color Palette Data register address (cgb)
+const AddrOCPD uint16 = 0xFF6B
+
+// ColorPalettes is the palette memory of the background or the sprites
+// (cgb), 8 palettes of 4 RGB555 colors that are accessed through the
+// specification register (index and auto increment) and the data register
+type ColorPalettes struct {
+	index byte
+	data  [64]byte
+}
+
+// Read from the specification or data register
+func (p *ColorPalettes) Read(addr uint16) (byte, error) {
+
+	if addr == AddrBCPS || addr == AddrOCPS {
+		return p.index, nil
+	}
+
+	return p.data[p.index&0x3F], nil
+}
+
+// Write to the specification or data register
+func (p *ColorPalettes) Write(addr uint16, data byte) error {
+
+	if addr == AddrBCPS || addr == AddrOCPS {
+		p.index = data & 0xBF
+		return nil
+	}
+
+	p.data[p.index&0x3F] = data
+
+	// auto increment
+	if p.index&0x80 == 0x80 {
+		p.index = 0x80 | ((p.index + 1) & 0x3F)
+	}
+
+	return nil
+}
+
+// pixel returns color 'code' (0 - 3) of palette 'n' (0 - 7)
+func (p *ColorPalettes) pixel(n, code byte) Pixel {
+
+	i := (n&0x07)*8 + code*2
+
+	return RGB555(uint16(p.data[i]) | uint16(p.data[i+1])<<8)
+}
+
+// reset sets all the colors to white
+func (p *ColorPalettes) reset() {
+
+	p.index = 0
+
+	for i := range p.data {
+		p.data[i] = 0xFF
+	}
+}
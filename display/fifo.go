@@ -0,0 +1,480 @@
+package display
+
+// the steps of the background fetcher, each read takes 2 dots
+const (
+	fetchTileID    byte = 0 // read the tile id (and the cgb attributes) from the map
+	fetchDataLow   byte = 1 // read the low byte of the tile row
+	fetchDataHigh  byte = 2 // read the high byte of the tile row
+	fetchPush      byte = 3 // push the row once the background fifo is empty
+	fetchStepDots  byte = 2
+	firstFetchDots byte = 6 // the first tile of every line is fetched twice
+)
+
+// spriteFetchDots is the number of dots the pixel output
+// is paused while the tile row of a sprite is fetched
+const spriteFetchDots byte = 6
+
+// maxLineSprites is the number of sprites per line
+const maxLineSprites int = 10
+
+// fifoPixel is a single pixel in one of the fifos, the palettes are
+// applied when the pixel is shifted out (mid-line palette writes count)
+type fifoPixel struct {
+	Code    byte // the color code (0 - 3)
+	Palette byte // obp0 / obp1 (dmg sprites) or the color palette (cgb)
+	Attr    byte // the bg map attributes (cgb) or the sprite attributes
+	OAM     byte // the oam index of the sprite (cgb sprite priority)
+}
+
+// pixelFIFO is a queue of up to 8 pixels
+type pixelFIFO struct {
+	Pixels [8]fifoPixel
+	Head   byte
+	Size   byte
+}
+
+// push a pixel to the end of the queue
+func (f *pixelFIFO) push(p fifoPixel) {
+	f.Pixels[(f.Head+f.Size)%8] = p
+	f.Size++
+}
+
+// pop the first pixel of the queue
+func (f *pixelFIFO) pop() fifoPixel {
+
+	p := f.Pixels[f.Head]
+
+	f.Head = (f.Head + 1) % 8
+	f.Size--
+
+	return p
+}
+
+// at returns the i'th pixel of the queue
+func (f *pixelFIFO) at(i byte) *fifoPixel {
+	return &f.Pixels[(f.Head+i)%8]
+}
+
+// clear the queue
+func (f *pixelFIFO) clear() {
+	f.Head = 0
+	f.Size = 0
+}
+
+// lineSprite is a sprite found by the oam search of the current line
+type lineSprite struct {
+	Attr    SpriteAttr
+	OAM     byte // the oam index
+	Fetched bool
+}
+
+// pipeline is the state of the pixel transfer (mode 3), the fetcher
+// fills the background fifo a tile row at a time, sprites are mixed
+// into the sprite fifo when the output reaches their x coordinate and
+// a pixel is shifted out to the screen every dot while the background
+// fifo is not empty (the fields are exported for the save state)
+type pipeline struct {
+	BG  pixelFIFO
+	OBJ pixelFIFO
+
+	Step     byte // the fetcher step
+	Dots     byte // the dots spent in the current step
+	TileX    byte // the next tile of the fetcher in the bg / window row
+	TileID   byte
+	TileAttr byte
+	DataLow  byte
+	DataHigh byte
+
+	Delay   byte // dots before the fetcher starts
+	Discard byte // pixels to drop (scx fine scroll or wx < 7)
+	Window  bool // the fetcher reads the window
+
+	Sprites      [maxLineSprites]lineSprite
+	SpritesCount byte
+	Sprite       int8 // the sprite being fetched (-1 for none)
+	SpriteDots   byte
+}
+
+// startLine prepares the pipeline for the pixel transfer of line ly
+func (g *GPU) startLine() {
+
+	p := &g.pipe
+
+	p.BG.clear()
+	p.OBJ.clear()
+	p.Step = fetchTileID
+	p.Dots = 0
+	p.TileX = 0
+	p.Delay = firstFetchDots
+	p.Discard = byte(g.scx) & 0x07
+	p.Window = false
+	p.Sprite = -1
+
+	// the window is shown from the first line that matches wy
+	if g.ly == byte(g.wy) {
+		g.winTriggered = true
+	}
+
+	g.lx = 0
+}
+
+// searchOAM selects the first 10 sprites (in oam order) on line ly
+func (g *GPU) searchOAM() error {
+
+	p := &g.pipe
+
+	p.SpritesCount = 0
+
+	h := g.lcdc.spriteWidth()
+
+	for id := 0; id < 40 && int(p.SpritesCount) < maxLineSprites; id++ {
+
+		attr, err := g.getSpriteAttribute(0xFE00 + uint16(id*4))
+
+		if err != nil {
+			return err
+		}
+
+		// y is the line + 16
+		y := int(g.ly) + 16 - int(attr.coordinateY())
+
+		if y < 0 || y >= int(h) {
+			continue
+		}
+
+		p.Sprites[p.SpritesCount] = lineSprite{Attr: *attr, OAM: byte(id)}
+		p.SpritesCount++
+	}
+
+	return nil
+}
+
+// renderDot runs a single dot of the pixel transfer,
+// returns true when the line is complete
+func (g *GPU) renderDot() bool {
+
+	p := &g.pipe
+
+	if p.Delay > 0 {
+		p.Delay--
+		return false
+	}
+
+	// the background fetcher and the output are paused during a sprite fetch
+	if p.Sprite >= 0 {
+
+		p.SpriteDots++
+
+		if p.SpriteDots < spriteFetchDots {
+			return false
+		}
+
+		g.fetchSprite(&p.Sprites[p.Sprite])
+		p.Sprite = -1
+	}
+
+	// the window restarts the fetcher with an empty fifo (at x 0
+	// once the first tile was fetched and the fine scroll dropped)
+	if (g.lx > 0 || p.BG.Size > 0) && p.Discard == 0 && !p.Window && g.windowStarts() {
+
+		p.BG.clear()
+		p.Window = true
+		p.Step = fetchTileID
+		p.Dots = 0
+		p.TileX = 0
+
+		if wx := byte(g.wx); wx < 7 {
+			p.Discard = 7 - wx
+		}
+	}
+
+	g.stepFetcher()
+
+	if p.BG.Size == 0 {
+		return false
+	}
+
+	if p.Discard > 0 {
+		p.BG.pop()
+		p.Discard--
+		return false
+	}
+
+	// a sprite starts here, its fetch waits until the current
+	// tile fetch reaches its last dot (5 dots at most)
+	if i := g.spriteAt(g.lx); i >= 0 {
+
+		if p.Step == fetchPush || (p.Step == fetchDataHigh && p.Dots == fetchStepDots-1) {
+			p.Sprite = i
+			p.SpriteDots = 0
+		}
+
+		return false
+	}
+
+	g.shiftPixel()
+
+	g.lx++
+
+	return g.lx == byte(ScreenWidth)
+}
+
+// windowStarts returns true if the window starts at the current x
+func (g *GPU) windowStarts() bool {
+
+	if !g.lcdc.windowEnabled() || !g.winTriggered {
+		return false
+	}
+
+	if wx := byte(g.wx); wx < 7 {
+		return g.lx == 0
+	}
+
+	return int(g.lx)+7 == int(byte(g.wx))
+}
+
+// spriteAt returns the next sprite (in oam order) that
+// starts at 'x' and wasn't fetched yet, -1 if none
+func (g *GPU) spriteAt(x byte) int8 {
+
+	if !g.lcdc.spritesEnabled() {
+		return -1
+	}
+
+	p := &g.pipe
+
+	for i := 0; i < int(p.SpritesCount); i++ {
+
+		s := &p.Sprites[i]
+
+		if s.Fetched {
+			continue
+		}
+
+		sx := s.Attr.coordinateX()
+
+		// sprites that are partially off the left edge start at x 0
+		if int(sx)-8 == int(x) || (x == 0 && 0 < sx && sx < 8) {
+			return int8(i)
+		}
+	}
+
+	return -1
+}
+
+// stepFetcher advances the background fetcher by a single dot
+func (g *GPU) stepFetcher() {
+
+	p := &g.pipe
+
+	if p.Step == fetchPush {
+
+		if p.BG.Size == 0 {
+			g.pushTileRow()
+		}
+
+		return
+	}
+
+	p.Dots++
+
+	if p.Dots < fetchStepDots {
+		return
+	}
+
+	p.Dots = 0
+
+	switch p.Step {
+
+	case fetchTileID:
+
+		addr := g.fetcherMapAddr()
+
+		p.TileID = g.vramByte(0, addr)
+		p.TileAttr = 0
+
+		if g.cgb {
+			p.TileAttr = g.vramByte(1, addr)
+		}
+
+	case fetchDataLow:
+		p.DataLow = g.vramByte((p.TileAttr>>3)&0x01, g.fetcherRowAddr())
+
+	case fetchDataHigh:
+		p.DataHigh = g.vramByte((p.TileAttr>>3)&0x01, g.fetcherRowAddr()+1)
+	}
+
+	p.Step++
+}
+
+// fetcherMapAddr returns the map address of the next tile
+func (g *GPU) fetcherMapAddr() uint16 {
+
+	p := &g.pipe
+
+	if p.Window {
+		return mapAddr(g.lcdc.windowMap()) + uint16(g.winLine/8)*32 + uint16(p.TileX&0x1F)
+	}
+
+	y := g.ly + byte(g.scy)
+	x := (byte(g.scx)/8 + p.TileX) & 0x1F
+
+	return mapAddr(g.lcdc.backgroundMap()) + uint16(y/8)*32 + uint16(x)
+}
+
+// fetcherRowAddr returns the address of the tile row of the fetched tile
+func (g *GPU) fetcherRowAddr() uint16 {
+
+	p := &g.pipe
+
+	row := (g.ly + byte(g.scy)) % 8
+
+	if p.Window {
+		row = g.winLine % 8
+	}
+
+	// vertical flip (cgb)
+	if p.TileAttr&0x40 == 0x40 {
+		row = 7 - row
+	}
+
+	return g.getTileAddr(p.TileID) + uint16(row)*2
+}
+
+// pushTileRow pushes the 8 pixels of the fetched tile row
+func (g *GPU) pushTileRow() {
+
+	p := &g.pipe
+
+	for i := byte(0); i < 8; i++ {
+
+		bit := 7 - i
+
+		// horizontal flip (cgb)
+		if p.TileAttr&0x20 == 0x20 {
+			bit = i
+		}
+
+		code := (p.DataLow>>bit)&0x01 | ((p.DataHigh>>bit)&0x01)<<1
+
+		p.BG.push(fifoPixel{Code: code, Palette: p.TileAttr & 0x07, Attr: p.TileAttr})
+	}
+
+	p.TileX++
+	p.Step = fetchTileID
+}
+
+// fetchSprite mixes the tile row of sprite 's' into the sprite fifo, an
+// opaque pixel is kept over a later sprite (dmg), in cgb mode the sprite
+// with the lower oam index wins
+func (g *GPU) fetchSprite(s *lineSprite) {
+
+	p := &g.pipe
+
+	s.Fetched = true
+
+	attr := &s.Attr
+	w := g.lcdc.spriteWidth()
+	y := g.ly + 16 - attr.coordinateY()
+
+	var bank, palette byte
+
+	if g.cgb {
+		bank = attr.bank()
+		palette = attr.colorPalette()
+	} else {
+		palette = attr.palette()
+	}
+
+	// the pixels left of the screen are dropped
+	var skip byte
+
+	if x := attr.coordinateX(); x < 8 {
+		skip = 8 - x
+	}
+
+	for x := skip; x < 8; x++ {
+
+		code := g.spriteColorCode(attr.tileID(), bank, w, x, y, attr.flipX(), attr.flipY())
+		px := fifoPixel{Code: code, Palette: palette, Attr: attr[3], OAM: s.OAM}
+
+		i := x - skip
+
+		if i >= p.OBJ.Size {
+			p.OBJ.push(px)
+			continue
+		}
+
+		if old := p.OBJ.at(i); old.Code == 0 || (g.cgb && code != 0 && s.OAM < old.OAM) {
+			*old = px
+		}
+	}
+}
+
+// shiftPixel shifts the next pixel out to the frame, mixed with the sprites
+func (g *GPU) shiftPixel() {
+
+	p := &g.pipe
+
+	bg := p.BG.pop()
+
+	var obj fifoPixel
+
+	if p.OBJ.Size > 0 {
+		obj = p.OBJ.pop()
+	}
+
+	var pixel Pixel
+
+	// in dmg mode lcdc bit 0 blanks the background and the window
+	if !g.cgb && !g.lcdc.backgroundEnabled() {
+		bg = fifoPixel{}
+		pixel = g.white()
+	} else {
+		pixel = g.bgPixel(bg)
+	}
+
+	if obj.Code != 0 && g.lcdc.spritesEnabled() && g.spriteOverBackground(bg, obj.Attr>>7) {
+		pixel = g.spritePixel(obj)
+	}
+
+	g.frame[g.lx][g.ly] = pixel
+}
+
+// bgPixel returns the color of a background (or window) pixel
+func (g *GPU) bgPixel(px fifoPixel) Pixel {
+
+	if g.cgb {
+		return g.bcp.pixel(px.Palette, px.Code)
+	}
+
+	if g.dmg != nil {
+		return g.dmg.BG[g.bgp.toColor(px.Code)]
+	}
+
+	return Pixel(g.bgp.toColor(px.Code))
+}
+
+// spritePixel returns the color of a sprite pixel
+func (g *GPU) spritePixel(px fifoPixel) Pixel {
+
+	if g.cgb {
+		return g.ocp.pixel(px.Palette, px.Code)
+	}
+
+	if g.dmg != nil {
+		return g.dmg.OBJ[px.Palette][g.obp[px.Palette].toColor(px.Code)]
+	}
+
+	return Pixel(g.obp[px.Palette].toColor(px.Code))
+}
+
+// mapAddr returns the address of map 0 or 1
+func mapAddr(n byte) uint16 {
+
+	if n == 0 {
+		return 0x9800
+	}
+
+	return 0x9C00
+}
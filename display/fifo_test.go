@@ -0,0 +1,96 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// TestModeLength drives the pixel transfer of line 0 and checks the
+// length of mode 3, the sprites are at y 16 (on line 0), 'x' is the
+// oam x coordinate of each sprite
+func TestModeLength(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		lcdc    LCDC
+		scx     byte
+		wx      byte
+		sprites []byte
+		dots    int
+	}{
+		{"scx 0", 0x91, 0, 0, nil, 172},
+		{"scx 3", 0x91, 3, 0, nil, 175},
+		{"scx 7", 0x91, 7, 0, nil, 179},
+		{"scx 8", 0x91, 8, 0, nil, 172},
+		{"sprite at tile start", 0x93, 0, 0, []byte{8}, 183},
+		{"sprite at tile start, scx 3", 0x93, 3, 0, []byte{13}, 186},
+		{"sprite at tile x 1", 0x93, 0, 0, []byte{9}, 182},
+		{"sprite at tile x 4", 0x93, 0, 0, []byte{12}, 179},
+		{"sprite at tile x 5", 0x93, 0, 0, []byte{13}, 178},
+		{"sprite at the last pixel", 0x93, 0, 0, []byte{167}, 178},
+		{"two sprites at the same x", 0x93, 0, 0, []byte{8, 8}, 189},
+		{"two sprites at different tiles", 0x93, 0, 0, []byte{8, 16}, 194},
+		{"sprites disabled", 0x91, 0, 0, []byte{8}, 172},
+		{"window at x 0", 0xB1, 0, 7, nil, 172},
+		{"window at x 1", 0xB1, 0, 8, nil, 178},
+		{"window at x 43", 0xB1, 0, 50, nil, 178},
+		{"window off screen", 0xB1, 0, 167, nil, 172},
+		{"window disabled", 0x91, 0, 50, nil, 172},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			mmu := memory.NewMMU()
+
+			core, err := cpu.NewCore(mmu)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := NewGPU(mmu, NewMemoryMonitor(nil), core, false)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g.lcdc = test.lcdc
+			g.scx = memory.MemReg(test.scx)
+			g.wx = memory.MemReg(test.wx)
+
+			for i, x := range test.sprites {
+
+				if err := mmu.Write(0xFE00+uint16(i*4), 16); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := mmu.Write(0xFE01+uint16(i*4), x); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := g.searchOAM(); err != nil {
+				t.Fatal(err)
+			}
+
+			g.startLine()
+
+			dots := 1
+
+			for !g.renderDot() {
+
+				if dots++; dots > 456 {
+					t.Fatal("the line didn't end")
+				}
+			}
+
+			if dots != test.dots {
+				t.Errorf("%d dots, want %d", dots, test.dots)
+			}
+		})
+	}
+}
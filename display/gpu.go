@@ -1,9 +1,9 @@
 package display
 
 import (
+	"encoding/binary"
 	"fmt"
-	"sort"
-	"time"
+	"io"
 
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/memory"
@@ -45,10 +45,13 @@ const AddrWinY uint16 = 0xFF4A
 // AddrWinX is the Window Y Position register address
 const AddrWinX uint16 = 0xFF4B
 
-// Layer is a part of a single frame
-type Layer [ScreenWidth][ScreenHeight]Color
+// AddrVBK is the VRAM Bank register address (cgb)
+const AddrVBK uint16 = 0xFF4F
 
-// GPU renders the background, window and sprites
+// bgPriority is the bg map attribute that puts the bg over the sprites (cgb)
+const bgPriority byte = 0x80
+
+// GPU renders the background, window and sprites with a pixel fifo
 type GPU struct {
 	monitor Monitor
 	core    *cpu.Core
@@ -63,44 +66,80 @@ type GPU struct {
 	ly   byte
 	lx   byte
 
+	stubLY bool // ly reads return $90 (gameboy-doctor)
+
 	bgp Palette
 	obp [2]Palette
 
 	stat STAT
 
-	bgLayer     Layer
-	winLayer    Layer
-	spriteLayer [2]Layer
+	frame Frame    // the frame being rendered
+	pipe  pipeline // the pixel transfer of the current line
+
+	winLine      byte // the window line counter (lines drawn in this frame)
+	winTriggered bool // ly matched wy in this frame
 
 	vram *memory.RAM
 	oam  *memory.RAM
 
-	sprites SpriteAttrs
+	cgb      bool
+	vbk      byte          // the selected vram bank (cgb)
+	vramData []byte        // both vram banks, read by the renderer
+	bcp      ColorPalettes // background palettes (cgb)
+	ocp      ColorPalettes // sprite palettes (cgb)
+	hdma     *HDMA         // vram dma (cgb)
+	dmg      *DMGPalettes  // colors of a monochrome game (nil for shades)
 
 	cyclesCounter int
 
-	displayEnabled    bool
-	spritesEnabled    bool
-	backgroundEnabled bool
+	displayEnabled bool
 
 	ignoreVBlankInt bool
 	ignoreHBlankInt bool
 	ignoreLYCInt    bool
 	ignoreOAMInt    bool
 
-	t1       time.Time
-	fps      int64
-	fpsCount int64
+	last *Frame // the last frame sent to the monitor
 }
 
-// NewGPU creates GPU instance
-func NewGPU(mmu *memory.MMU, monitor Monitor, core *cpu.Core, fps uint32) (*GPU, error) {
+// gpuState is the serialized form of the gpu
+type gpuState struct {
+	LCDC            byte
+	STAT            byte
+	SCY             byte
+	SCX             byte
+	WY              byte
+	WX              byte
+	LYC             byte
+	LY              byte
+	LX              byte
+	BGP             byte
+	OBP             [2]byte
+	VBK             byte
+	BCPIndex        byte
+	BCP             [64]byte
+	OCPIndex        byte
+	OCP             [64]byte
+	HDMA            hdmaState
+	CyclesCounter   int32
+	DisplayEnabled  bool
+	IgnoreVBlankInt bool
+	IgnoreHBlankInt bool
+	IgnoreLYCInt    bool
+	IgnoreOAMInt    bool
+	WinLine         byte
+	WinTriggered    bool
+	Pipeline        pipeline
+	Frame           Frame
+	Last            Frame // the last frame sent to the monitor
+}
 
-	if fps == 0 {
-		fps = 1
-	}
+// NewGPU creates GPU instance, 'cgb' selects the cgb mode (two
+// vram banks, color palettes, bg map attributes and vram dma)
+func NewGPU(mmu *memory.MMU, monitor Monitor, core *cpu.Core, cgb bool) (*GPU, error) {
 
-	g := GPU{mmu: mmu, monitor: monitor, core: core, fps: int64(fps)}
+	g := GPU{mmu: mmu, monitor: monitor, core: core, cgb: cgb}
+	g.hdma = newHDMA(&g)
 
 	g.lcdc = 0x91
 
@@ -164,12 +203,45 @@ func NewGPU(mmu *memory.MMU, monitor Monitor, core *cpu.Core, fps uint32) (*GPU,
 		return nil, err
 	}
 
-	// vram
-	g.vram = memory.NewRAM(make([]byte, 8192), 0x8000)
+	// vram (two banks in cgb mode)
+	banks := 1
+
+	if cgb {
+		banks = 2
+	}
+
+	g.vramData = make([]byte, banks*8192)
+	g.vram = memory.NewRAM(g.vramData, 0x8000)
 	if err := mmu.Map(&g, 0x8000, 0x9FFF); err != nil {
 		panic(err)
 	}
 
+	if cgb {
+
+		// vbk
+		if err := mmu.Map(&g, AddrVBK, AddrVBK); err != nil {
+			return nil, err
+		}
+
+		// bcps, bcpd
+		if err := mmu.Map(&g.bcp, AddrBCPS, AddrBCPD); err != nil {
+			return nil, err
+		}
+
+		// ocps, ocpd
+		if err := mmu.Map(&g.ocp, AddrOCPS, AddrOCPD); err != nil {
+			return nil, err
+		}
+
+		// hdma1 - hdma5
+		if err := mmu.Map(g.hdma, AddrHDMA1, AddrHDMA5); err != nil {
+			return nil, err
+		}
+
+		g.bcp.reset()
+		g.ocp.reset()
+	}
+
 	// oam
 	g.oam = memory.NewRAM(make([]byte, 160), 0xFE00)
 	if err := mmu.Map(&g, 0xFE00, 0xFE9F); err != nil {
@@ -182,13 +254,27 @@ func NewGPU(mmu *memory.MMU, monitor Monitor, core *cpu.Core, fps uint32) (*GPU,
 	return &g, nil
 }
 
-// Read from ly, dma, oam or vram
+// StubLY makes the ly reads return $90 (as gameboy-doctor expects)
+func (g *GPU) StubLY(on bool) {
+	g.stubLY = on
+}
+
+// Read from ly, dma, vbk, oam or vram
 func (g *GPU) Read(addr uint16) (byte, error) {
 
 	if addr == AddrLY {
+
+		if g.stubLY {
+			return 0x90, nil
+		}
+
 		return g.ly, nil
 	}
 
+	if addr == AddrVBK {
+		return g.vbk, nil
+	}
+
 	if addr == AddrDMA {
 		return 0, nil
 	}
@@ -204,7 +290,7 @@ func (g *GPU) Read(addr uint16) (byte, error) {
 	return 0, memory.ReadOutOfRangeError(addr)
 }
 
-// Write to ly, dma, oam or vram
+// Write to ly, dma, vbk, oam or vram
 func (g *GPU) Write(addr uint16, data byte) error {
 
 	if addr == AddrLY {
@@ -212,6 +298,11 @@ func (g *GPU) Write(addr uint16, data byte) error {
 		return nil
 	}
 
+	if addr == AddrVBK {
+		g.vbk = data & 0x01
+		return g.vram.SetWindow(uint32(g.vbk) * 8192)
+	}
+
 	if addr == AddrDMA {
 
 		//if g.stat.ModeFlag() == ModeSearchingOAM || g.stat.ModeFlag() == ModeTransferingDataToLCD {
@@ -242,62 +333,125 @@ func (g *GPU) Write(addr uint16, data byte) error {
 	return memory.WriteOutOfRangeError(addr)
 }
 
-// transferDataToOAM from the given address * 100
-func (g *GPU) transferDataToOAM(from byte) error {
-
-	src := uint16(from) << 8
-
-	for i := uint16(0); i < 0xA0; i++ {
-
-		v, err := g.mmu.Read(src + i)
-
-		if err != nil {
-			return err
-		}
+// SaveState writes the gpu registers, frames, vram and oam to 'w'
+func (g *GPU) SaveState(w io.Writer) error {
+
+	s := gpuState{
+		LCDC:            byte(g.lcdc),
+		STAT:            byte(g.stat),
+		SCY:             byte(g.scy),
+		SCX:             byte(g.scx),
+		WY:              byte(g.wy),
+		WX:              byte(g.wx),
+		LYC:             byte(g.lyc),
+		LY:              g.ly,
+		LX:              g.lx,
+		BGP:             byte(g.bgp),
+		OBP:             [2]byte{byte(g.obp[0]), byte(g.obp[1])},
+		VBK:             g.vbk,
+		BCPIndex:        g.bcp.index,
+		BCP:             g.bcp.data,
+		OCPIndex:        g.ocp.index,
+		OCP:             g.ocp.data,
+		HDMA:            g.hdma.state(),
+		CyclesCounter:   int32(g.cyclesCounter),
+		DisplayEnabled:  g.displayEnabled,
+		IgnoreVBlankInt: g.ignoreVBlankInt,
+		IgnoreHBlankInt: g.ignoreHBlankInt,
+		IgnoreLYCInt:    g.ignoreLYCInt,
+		IgnoreOAMInt:    g.ignoreOAMInt,
+		WinLine:         g.winLine,
+		WinTriggered:    g.winTriggered,
+		Pipeline:        g.pipe,
+		Frame:           g.frame,
+		Last:            g.frame}
+
+	if g.last != nil {
+		s.Last = *g.last
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, &s); err != nil {
+		return err
+	}
 
-		if err := g.mmu.Write(0xFE00+i, v); err != nil {
-			return err
-		}
+	if err := g.vram.SaveState(w); err != nil {
+		return err
 	}
 
-	return nil
+	return g.oam.SaveState(w)
 }
 
-// scanPixels scans next n (or less) pixels
-// returns true at the end of the scanline
-func (g *GPU) scanPixels(n byte) (bool, error) {
+// LoadState reads the gpu registers, frames, vram and oam from 'r'
+func (g *GPU) LoadState(r io.Reader) error {
 
-	for ; g.lx < 160 && n > 0; g.lx++ {
-		if err := g.renderPixel(g.lx, g.ly); err != nil {
-			return false, err
-		}
+	var s gpuState
 
-		if g.lx%16 == 0 {
-			if err := g.renderSprite(); err != nil {
-				return false, err
-			}
-		}
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
 
-		n--
+	if p := s.Pipeline; int(p.SpritesCount) > maxLineSprites || int(p.Sprite) >= int(p.SpritesCount) ||
+		p.BG.Size > 8 || p.OBJ.Size > 8 || p.Step > fetchPush || s.LX > byte(ScreenWidth) {
+		return fmt.Errorf("invalid pixel transfer state")
+	}
+
+	g.lcdc = LCDC(s.LCDC)
+	g.stat = STAT(s.STAT)
+	g.scy = memory.MemReg(s.SCY)
+	g.scx = memory.MemReg(s.SCX)
+	g.wy = memory.MemReg(s.WY)
+	g.wx = memory.MemReg(s.WX)
+	g.lyc = memory.MemReg(s.LYC)
+	g.ly = s.LY
+	g.lx = s.LX
+	g.bgp = Palette(s.BGP)
+	g.obp[0] = Palette(s.OBP[0])
+	g.obp[1] = Palette(s.OBP[1])
+	g.vbk = s.VBK & 0x01
+	g.bcp.index = s.BCPIndex
+	g.bcp.data = s.BCP
+	g.ocp.index = s.OCPIndex
+	g.ocp.data = s.OCP
+	g.hdma.setState(s.HDMA)
+	g.cyclesCounter = int(s.CyclesCounter)
+	g.displayEnabled = s.DisplayEnabled
+	g.ignoreVBlankInt = s.IgnoreVBlankInt
+	g.ignoreHBlankInt = s.IgnoreHBlankInt
+	g.ignoreLYCInt = s.IgnoreLYCInt
+	g.ignoreOAMInt = s.IgnoreOAMInt
+	g.winLine = s.WinLine
+	g.winTriggered = s.WinTriggered
+	g.pipe = s.Pipeline
+	g.frame = s.Frame
+	g.last = &s.Last
+
+	if err := g.vram.LoadState(r); err != nil {
+		return err
 	}
 
-	if g.lx == 160 {
-		g.lx = 0
-		return true, nil
+	if err := g.vram.SetWindow(uint32(g.vbk) * 8192); err != nil {
+		return err
 	}
 
-	return false, nil
+	return g.oam.LoadState(r)
 }
 
-// renderPixel (x,y) for the background and window
-func (g *GPU) renderPixel(x, y byte) error {
+// transferDataToOAM from the given address * 100
+func (g *GPU) transferDataToOAM(from byte) error {
 
-	if err := g.renderBackgroundPixel(x, y); err != nil {
-		return err
-	}
+	src := uint16(from) << 8
 
-	if err := g.renderWindowPixel(x, y); err != nil {
-		return err
+	for i := uint16(0); i < 0xA0; i++ {
+
+		v, err := g.mmu.Read(src + i)
+
+		if err != nil {
+			return err
+		}
+
+		if err := g.mmu.Write(0xFE00+i, v); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -306,7 +460,6 @@ func (g *GPU) renderPixel(x, y byte) error {
 // initialize the GPU
 func (g *GPU) initialize() {
 
-	g.t1 = time.Now()
 	g.ly = 0
 	g.lx = 0
 	g.cyclesCounter = 0
@@ -316,21 +469,45 @@ func (g *GPU) initialize() {
 	g.ignoreLYCInt = false
 	g.ignoreOAMInt = false
 	g.ignoreVBlankInt = false
-	g.sprites = nil
-
-	g.spritesEnabled = g.lcdc.spritesEnabled()
-	g.backgroundEnabled = g.lcdc.backgroundEnabled()
+	g.winLine = 0
+	g.winTriggered = false
+	g.pipe = pipeline{Sprite: -1}
 
 	for x := 0; x < ScreenWidth; x++ {
 		for y := 0; y < ScreenHeight; y++ {
-			g.winLayer[x][y] = ColorTransparent
-			g.bgLayer[x][y] = ColorWhite
-			g.spriteLayer[SpriteAboveBackground][x][y] = ColorTransparent
-			g.spriteLayer[SpriteBelowBackground][x][y] = ColorTransparent
+			g.frame[x][y] = g.white()
 		}
 	}
 }
 
+// white returns a white background pixel
+func (g *GPU) white() Pixel {
+
+	if g.cgb || g.dmg != nil {
+		return RGB555(0x7FFF)
+	}
+
+	return PixelWhite
+}
+
+// SetDMGPalettes colorizes a monochrome game (dmg mode), the background
+// and the two sprite palettes are drawn in separate colors, nil restores
+// the shades
+func (g *GPU) SetDMGPalettes(p *DMGPalettes) {
+	g.dmg = p
+}
+
+// Redraw sends the last complete frame to the monitor again (e.g. after
+// the state was loaded), the frame being rendered is not drawn
+func (g *GPU) Redraw() error {
+
+	if g.last == nil {
+		return g.updateMonitor()
+	}
+
+	return g.monitor.DrawFrame(g.last)
+}
+
 // ClockChanged is called after every instruction execution
 func (g *GPU) ClockChanged(cycles int) error {
 
@@ -406,25 +583,11 @@ func (g *GPU) ClockChanged(cycles int) error {
 
 		g.cyclesCounter = g.cyclesCounter - 80
 
-		for x := 0; x < ScreenWidth; x++ {
-			g.spriteLayer[SpriteAboveBackground][x][g.ly] = ColorTransparent
-			g.spriteLayer[SpriteBelowBackground][x][g.ly] = ColorTransparent
+		if err := g.searchOAM(); err != nil {
+			return err
 		}
 
-		if g.spritesEnabled {
-
-			attrs, err := g.searchOAM()
-
-			if err != nil {
-				return err
-			}
-
-			if len(attrs) > 10 {
-				attrs = attrs[0:10]
-			}
-
-			g.sprites = attrs
-		}
+		g.startLine()
 
 		g.stat.setModeFlag(ModeTransferingDataToLCD)
 
@@ -434,16 +597,31 @@ func (g *GPU) ClockChanged(cycles int) error {
 
 	case ModeTransferingDataToLCD:
 
-		hb, err := g.scanPixels(byte(cycles))
+		// the length of the transfer depends on the scroll,
+		// the window and the sprites of the line
+		hb := false
 
-		if err != nil {
-			return err
+		for ; cycles > 0 && !hb; cycles-- {
+			g.cyclesCounter++
+			hb = g.renderDot()
 		}
 
+		// the rest of the cycles are spent in h-blank
+		g.cyclesCounter += cycles
+
 		if hb {
 
+			if g.pipe.Window {
+				g.winLine++
+			}
+
 			g.stat.setModeFlag(ModeDuringHBlank)
 
+			// h-blank vram dma (cgb)
+			if err := g.hdma.hblank(); err != nil {
+				return err
+			}
+
 			if g.stat.hBlankInterruptEnabled() && !g.ignoreHBlankInt {
 				// request lcd status interrupt
 				g.core.RequestInterrupt(cpu.LCDStatusTriggersFlag)
@@ -468,11 +646,12 @@ func (g *GPU) ClockChanged(cycles int) error {
 
 		g.cyclesCounter += cycles
 
-		if g.cyclesCounter < 204 {
+		// the transfer and the h-blank take the rest of the 456 dots line
+		if g.cyclesCounter < 376 {
 			return nil
 		}
 
-		g.cyclesCounter = g.cyclesCounter - 204
+		g.cyclesCounter = g.cyclesCounter - 376
 
 		if g.ly == 144 {
 
@@ -533,8 +712,8 @@ func (g *GPU) ClockChanged(cycles int) error {
 
 			g.ignoreLYCInt = false
 
-			g.spritesEnabled = g.lcdc.spritesEnabled()
-			g.backgroundEnabled = g.lcdc.backgroundEnabled()
+			g.winLine = 0
+			g.winTriggered = false
 
 			g.ly = 0
 			g.stat.setModeFlag(ModeSearchingOAM)
@@ -552,153 +731,9 @@ func (g *GPU) ClockChanged(cycles int) error {
 	return nil
 }
 
-// renderBackgroundPixel renders (x, y) pixel of the background
-func (g *GPU) renderBackgroundPixel(x, y byte) error {
-
-	if !g.backgroundEnabled {
-		g.bgLayer[x][y] = ColorWhite
-		return nil
-	}
-
-	scx := byte(g.scx)
-	scy := byte(g.scy)
-
-	// find the tile in map and pixel within the tile for (x, y)
-	tileX := (x + scx) / 8  // 0 - 31
-	tileY := (y + scy) / 8  // 0 - 31
-	tilePX := (x + scx) % 8 // 0 - 7
-	tilePY := (y + scy) % 8 // 0 - 7
-
-	// find the tile offset in map
-	tileOff := uint16(tileX) + (uint16(tileY) * 32) // 0 - 1023
-
-	// set map address
-	var mapAddr uint16
-
-	mapN := g.lcdc.backgroundMap()
-
-	if mapN == 0 {
-		mapAddr = 0x9800
-	} else {
-		mapAddr = 0x9C00
-	}
-
-	// get tile id
-	tileID, err := g.mmu.Read(mapAddr + tileOff)
-
-	if err != nil {
-		return err
-	}
-
-	// set tile address
-	tileAddr := g.getTileAddr(tileID)
-
-	// get row bytes
-	rowByte1N := tilePY * 2    // 0 - 15
-	rowByte2N := rowByte1N + 1 // 0 - 15
-
-	rowByte1, err := g.mmu.Read(tileAddr + uint16(rowByte1N))
-
-	if err != nil {
-		return err
-	}
-
-	rowByte2, err := g.mmu.Read(tileAddr + uint16(rowByte2N))
-
-	if err != nil {
-		return err
-	}
-
-	// extract color code
-	rowByte1 = (rowByte1 << tilePX) >> 7
-	rowByte2 = ((rowByte2 << tilePX) >> 7) << 1
-
-	colorCode := rowByte1 | rowByte2
-
-	// set color for pixel (x, y)
-	g.bgLayer[x][y] = g.bgp.toColor(colorCode)
-
-	return nil
-}
-
-// renderWindowPixel renders (x, y) pixel of the window
-func (g *GPU) renderWindowPixel(x, y byte) error {
-
-	if !g.lcdc.windowEnabled() {
-		g.winLayer[x][y] = ColorTransparent
-		return nil
-	}
-
-	wx := byte(g.wx)
-
-	if wx < 7 {
-		return nil
-	}
-
-	wy := byte(g.wy)
-
-	if x < (wx-7) || y < wy {
-		g.winLayer[x][y] = ColorTransparent
-		return nil
-	}
-
-	// find the tile in map and pixel within the tile for (x, y)
-	tileX := (x - (wx - 7)) / 8 // 0 - 19
-	tileY := (y - wy) / 8       // 0 - 17
-
-	tilePX := (x - (wx - 7)) % 8 // 0 - 7
-	tilePY := (y - wy) % 8       // 0 - 7
-
-	// find the tile offset in map
-	tileOff := uint16(tileX) + (uint16(tileY) * 32) // 0 - 563
-
-	// set map address
-	var mapAddr uint16
-
-	mapN := g.lcdc.windowMap()
-
-	if mapN == 0 {
-		mapAddr = 0x9800
-	} else {
-		mapAddr = 0x9C00
-	}
-
-	// get tile id
-	tileID, err := g.mmu.Read(mapAddr + tileOff)
-
-	if err != nil {
-		return err
-	}
-
-	// set tile address
-	tileAddr := g.getTileAddr(tileID)
-
-	// get row bytes
-	rowByte1N := tilePY * 2    // 0 - 15
-	rowByte2N := rowByte1N + 1 // 0 - 15
-
-	rowByte1, err := g.mmu.Read(tileAddr + uint16(rowByte1N))
-
-	if err != nil {
-		return err
-	}
-
-	rowByte2, err := g.mmu.Read(tileAddr + uint16(rowByte2N))
-
-	if err != nil {
-		return err
-	}
-
-	// extract color code
-	rowByte1 = (rowByte1 << tilePX) >> 7
-	rowByte2 = ((rowByte2 << tilePX) >> 7) << 1
-
-	colorCode := rowByte1 | rowByte2
-
-	// set color for pixel (x, y)
-	g.winLayer[x][y] = g.bgp.toColor(colorCode)
-
-	return nil
+// vramByte reads address 'addr' (8000-9FFF) of vram bank 'bank'
+func (g *GPU) vramByte(bank byte, addr uint16) byte {
+	return g.vramData[int(bank)*8192+int(addr-0x8000)]
 }
 
 // getTileAddr from tile id and tileset
@@ -716,65 +751,37 @@ func (g *GPU) getTileAddr(id byte) uint16 {
 	return 0x8000 + (16 * uint16(id)) // id: 0 - 255
 }
 
-// createFrame from background, window and sprites
-func (g *GPU) createFrame() *Frame {
+// spriteOverBackground returns true if a sprite with the
+// given priority is drawn over the background pixel 'bg'
+func (g *GPU) spriteOverBackground(bg fifoPixel, priority byte) bool {
 
-	var f Frame
-
-	for x := 0; x < ScreenWidth; x++ {
-		for y := 0; y < ScreenHeight; y++ {
-
-			// merge bg and window
-			if g.winLayer[x][y] == ColorTransparent {
-				f[x][y] = Pixel(g.bgLayer[x][y])
-			} else {
-				f[x][y] = Pixel(g.winLayer[x][y])
-			}
-
-			// merge sprites
-			if g.spriteLayer[SpriteAboveBackground][x][y] != ColorTransparent {
-
-				f[x][y] = Pixel(g.spriteLayer[SpriteAboveBackground][x][y])
-
-			} else {
+	// color 0 is always behind the sprites
+	if bg.Code == 0 {
+		return true
+	}
 
-				if f[x][y] == PixelWhite {
+	if g.cgb {
 
-					if g.spriteLayer[SpriteBelowBackground][x][y] != ColorTransparent {
+		// lcdc bit 0 is the background master priority
+		if !g.lcdc.backgroundEnabled() {
+			return true
+		}
 
-						f[x][y] = Pixel(g.spriteLayer[SpriteBelowBackground][x][y])
-					}
-				}
-			}
+		if bg.Attr&bgPriority == bgPriority {
+			return false
 		}
 	}
 
-	return &f
+	return priority == SpriteAboveBackground
 }
 
-// updateMonitor with the bg, window and sprites rendered layers
+// updateMonitor with the rendered frame
 func (g *GPU) updateMonitor() error {
 
-	err := g.monitor.DrawFrame(g.createFrame())
-
-	g.fpsCount++
-
-	if g.fpsCount == g.fps {
-
-		g.fpsCount = 0
-
-		t2 := time.Since(g.t1)
-
-		if t2.Nanoseconds() > time.Second.Nanoseconds() {
-			g.core.Throttle(false)
-		} else if t2.Nanoseconds() < time.Second.Nanoseconds() {
-			g.core.Throttle(true)
-		}
+	f := g.frame
+	g.last = &f
 
-		g.t1 = time.Now()
-	}
-
-	return err
+	return g.monitor.DrawFrame(g.last)
 }
 
 /////////////
@@ -798,86 +805,8 @@ func (g *GPU) getSpriteAttribute(addr uint16) (*SpriteAttr, error) {
 	return &attr, nil
 }
 
-// searchOAM for current ly sprites
-func (g *GPU) searchOAM() (SpriteAttrs, error) {
-
-	var attrs SpriteAttrs
-
-	for id := 0; id < 40; id++ {
-
-		attrAddr := 0xFE00 + uint16(id*4)
-
-		// get the sprite attribute
-		attr, err := g.getSpriteAttribute(attrAddr)
-
-		if err != nil {
-			return nil, err
-		}
-
-		w := g.lcdc.spriteWidth()
-
-		if offScreen(attr.coordinateX(), 8, 8, 159) || offScreen(attr.coordinateY(), 16, w, 143) {
-			// sprite is off screen
-			continue
-		}
-
-		ys, ye, _ := calcCoords(attr.coordinateY(), 16, w, 143)
-
-		if ys <= g.ly && g.ly <= ye {
-			attrs = append(attrs, attr)
-		}
-	}
-
-	sort.Sort(attrs)
-
-	return attrs, nil
-}
-
-// renderSprite from sprites list
-func (g *GPU) renderSprite() error {
-
-	if len(g.sprites) == 0 {
-		return nil
-	}
-
-	attr := g.sprites[0]
-
-	y := g.ly
-
-	w := g.lcdc.spriteWidth()
-
-	xs, xe, spx := calcCoords(attr.coordinateX(), 8, 8, 159)
-	ys, _, spy := calcCoords(attr.coordinateY(), 16, w, 143)
-	flipX := attr.flipX()
-	flipY := attr.flipY()
-	priority := attr.priority()
-	palette := g.obp[attr.palette()]
-	tileID := attr.tileID()
-	spy = spy + (y - ys)
-
-	for x := xs; x <= xe; x++ {
-
-		colorCode, err := g.spriteColorCode(tileID, w, spx, spy, flipX, flipY)
-
-		if err != nil {
-			return err
-		}
-
-		if g.spriteLayer[priority][x][y] == ColorTransparent && colorCode != 0 {
-
-			g.spriteLayer[priority][x][y] = palette.toColor(colorCode)
-		}
-
-		spx++
-	}
-
-	g.sprites = g.sprites[1:]
-
-	return nil
-}
-
 // spriteColorCode returns the color code for a given pixel in a given sprite
-func (g *GPU) spriteColorCode(id byte, width byte, x, y byte, flipX, flipY bool) (byte, error) {
+func (g *GPU) spriteColorCode(id byte, bank byte, width byte, x, y byte, flipX, flipY bool) byte {
 
 	// horizontal flip
 	if flipX {
@@ -904,56 +833,12 @@ func (g *GPU) spriteColorCode(id byte, width byte, x, y byte, flipX, flipY bool)
 	}
 
 	// get row bytes
-	rowByte0, err := g.mmu.Read(addr)
-
-	if err != nil {
-		return 0, err
-	}
-
-	rowByte1, err := g.mmu.Read(addr + 1)
-
-	if err != nil {
-		return 0, err
-	}
+	rowByte0 := g.vramByte(bank, addr)
+	rowByte1 := g.vramByte(bank, addr+1)
 
 	// extract color code
 	rowByte0 = (rowByte0 << x) >> 7
 	rowByte1 = ((rowByte1 << x) >> 7) << 1
 
-	return rowByte0 | rowByte1, nil
-}
-
-func offScreen(i, off, len, max byte) bool {
-	return max+off < i || i <= off-len
-}
-
-func calcCoords(i, off, len, max byte) (byte, byte, byte) {
-
-	var start byte
-	var end byte
-	var spr byte
-
-	if off-len < i && i < off {
-
-		start = 0
-		end = len - (off - i) - 1
-		spr = off - i
-
-	} else if off <= i && i <= max+off-len+1 {
-
-		start = i - off
-		end = i - off + len - 1
-
-	} else if max+off-len+2 <= i && i <= max+off {
-
-		start = i - off
-		end = max
-
-	} else {
-
-		// off screen
-		panic("sprite off screen")
-	}
-
-	return start, end, spr
+	return rowByte0 | rowByte1
 }
@@ -0,0 +1,202 @@
+package display
+
+// AddrHDMA1 is the VRAM DMA Source (high) register address (cgb)
+const AddrHDMA1 uint16 = 0xFF51
+
+// AddrHDMA2 is the VRAM DMA Source (low) register address (cgb)
+const AddrHDMA2 uint16 = 0xFF52
+
+// AddrHDMA3 is the VRAM DMA Destination (high) register address (cgb)
+const AddrHDMA3 uint16 = 0xFF53
+
+// AddrHDMA4 is the VRAM DMA Destination (low) register address (cgb)
+const AddrHDMA4 uint16 = 0xFF54
+
+// AddrHDMA5 is the VRAM DMA Length/Mode/Start register address (cgb)
+const AddrHDMA5 uint16 = 0xFF55
+
+// hdmaBlockCycles is the number of (normal speed) cycles
+// the cpu is stalled for every 16 bytes block
+const hdmaBlockCycles = 32
+
+// HDMA is the vram dma unit (cgb), a general purpose transfer copies all
+// the blocks at once, an h-blank transfer copies a single 16 bytes block
+// at the start of every h-blank, the cpu is stalled during the copy
+type HDMA struct {
+	g      *GPU
+	src    uint16
+	dst    uint16 // offset in vram (0000-1FF0)
+	blocks byte   // the remaining number of blocks - 1
+	active bool   // an h-blank transfer is in progress
+}
+
+// hdmaState is the serialized form of the vram dma unit
+type hdmaState struct {
+	Src    uint16
+	Dst    uint16
+	Blocks byte
+	Active bool
+}
+
+// newHDMA creates HDMA instance
+func newHDMA(g *GPU) *HDMA {
+	return &HDMA{g: g, blocks: 0x7F}
+}
+
+// Read the transfer status (HDMA5), bit 7 is clear while an h-blank
+// transfer is in progress, bits 0 - 6 are the remaining blocks - 1
+func (h *HDMA) Read(addr uint16) (byte, error) {
+
+	if addr != AddrHDMA5 {
+		return 0xFF, nil
+	}
+
+	if h.active {
+		return h.blocks, nil
+	}
+
+	return 0x80 | h.blocks, nil
+}
+
+// Write to the address registers or start (or cancel) a transfer
+func (h *HDMA) Write(addr uint16, data byte) error {
+
+	switch addr {
+
+	case AddrHDMA1:
+		h.src = h.src&0x00FF | uint16(data)<<8
+
+	case AddrHDMA2:
+		h.src = h.src&0xFF00 | uint16(data&0xF0)
+
+	case AddrHDMA3:
+		h.dst = h.dst&0x00FF | uint16(data&0x1F)<<8
+
+	case AddrHDMA4:
+		h.dst = h.dst&0xFF00 | uint16(data&0xF0)
+
+	case AddrHDMA5:
+
+		// cancel the h-blank transfer
+		if h.active && data&0x80 == 0 {
+			h.active = false
+			return nil
+		}
+
+		h.blocks = data & 0x7F
+
+		// h-blank transfer
+		if data&0x80 == 0x80 {
+
+			h.active = true
+
+			// no h-blank with the display off, the first block is copied now
+			if !h.g.lcdc.displayEnabled() {
+				return h.hblank()
+			}
+
+			return nil
+		}
+
+		// general purpose transfer
+		blocks := int(h.blocks) + 1
+
+		for i := 0; i < blocks; i++ {
+
+			done, err := h.copyBlock()
+
+			if err != nil {
+				return err
+			}
+
+			if done {
+				blocks = i + 1
+				break
+			}
+		}
+
+		h.blocks = 0x7F
+		h.stall(blocks)
+	}
+
+	return nil
+}
+
+// hblank copies the next block of an h-blank transfer
+func (h *HDMA) hblank() error {
+
+	if !h.active {
+		return nil
+	}
+
+	done, err := h.copyBlock()
+
+	if err != nil {
+		return err
+	}
+
+	h.stall(1)
+
+	if done || h.blocks == 0 {
+		h.blocks = 0x7F
+		h.active = false
+		return nil
+	}
+
+	h.blocks--
+
+	return nil
+}
+
+// copyBlock copies 16 bytes to the selected vram bank, returns true
+// when the destination reached the end of vram (the transfer ends)
+func (h *HDMA) copyBlock() (bool, error) {
+
+	for i := uint16(0); i < 16; i++ {
+
+		data, err := h.g.mmu.Read(h.src + i)
+
+		if err != nil {
+			return false, err
+		}
+
+		if err := h.g.vram.Write(0x8000+h.dst+i, data); err != nil {
+			return false, err
+		}
+	}
+
+	h.src += 16
+	h.dst += 16
+
+	if h.dst > 0x1FF0 {
+		h.dst &= 0x1FF0
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// stall the cpu for the copy of 'blocks' blocks
+func (h *HDMA) stall(blocks int) {
+
+	cycles := blocks * hdmaBlockCycles
+
+	if h.g.core.DoubleSpeed() {
+		cycles *= 2
+	}
+
+	h.g.core.Stall(cycles)
+}
+
+// state returns the serialized form of the unit
+func (h *HDMA) state() hdmaState {
+	return hdmaState{Src: h.src, Dst: h.dst, Blocks: h.blocks, Active: h.active}
+}
+
+// setState restores the unit from its serialized form
+func (h *HDMA) setState(s hdmaState) {
+	h.src = s.Src
+	h.dst = s.Dst & 0x1FF0
+	h.blocks = s.Blocks & 0x7F
+	h.active = s.Active
+}
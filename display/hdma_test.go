@@ -0,0 +1,237 @@
+package display
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// coreState is the layout of the core save state, it is
+// used to read the stall and to switch to double speed
+type coreState struct {
+	AF, BC, DE, HL, SP, PC uint16
+
+	IME        bool
+	IE, IF     byte
+	Halt, Stop bool
+
+	DoubleSpeed bool
+	SpeedSwitch bool
+	Stall       int32
+}
+
+// testHDMA creates a cgb gpu, the work ram (c000 - dfff) is
+// filled with the low byte of the address plus 1
+func testHDMA(t *testing.T, doubleSpeed bool) *GPU {
+
+	mmu := memory.NewMMU()
+
+	wram := make([]byte, 0x2000)
+
+	for i := range wram {
+		wram[i] = byte(i) + 1
+	}
+
+	if err := mmu.Map(memory.NewRAM(wram, 0xC000), 0xC000, 0xDFFF); err != nil {
+		t.Fatal(err)
+	}
+
+	core, err := cpu.NewCore(mmu)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGPU(mmu, NewMemoryMonitor(nil), core, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doubleSpeed {
+
+		s := readCoreState(t, core)
+		s.DoubleSpeed = true
+
+		var buf bytes.Buffer
+
+		if err := binary.Write(&buf, binary.LittleEndian, &s); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := core.LoadState(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return g
+}
+
+// readCoreState returns the saved state of 'core'
+func readCoreState(t *testing.T, core *cpu.Core) coreState {
+
+	var buf bytes.Buffer
+
+	if err := core.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var s coreState
+
+	if err := binary.Read(&buf, binary.LittleEndian, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// startHDMA writes the source, the destination and then 'hdma5'
+func startHDMA(t *testing.T, g *GPU, src, dst uint16, hdma5 byte) {
+
+	regs := []struct {
+		addr uint16
+		data byte
+	}{
+		{AddrHDMA1, byte(src >> 8)},
+		{AddrHDMA2, byte(src)},
+		{AddrHDMA3, byte(dst >> 8)},
+		{AddrHDMA4, byte(dst)},
+		{AddrHDMA5, hdma5},
+	}
+
+	for _, r := range regs {
+		if err := g.mmu.Write(r.addr, r.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkHDMA checks the number of bytes copied to vram
+// (from 'dst'), the cpu stall and the value of HDMA5
+func checkHDMA(t *testing.T, g *GPU, dst uint16, copied int, stall int32, hdma5 byte) {
+
+	for i := 0; i < 0x2000-int(dst); i++ {
+
+		want := byte(0)
+
+		if i < copied {
+			want = byte(i) + 1
+		}
+
+		if data := g.vramData[int(dst)+i]; data != want {
+			t.Fatalf("vram %04x = %02x, want %02x (%d bytes copied)", 0x8000+int(dst)+i, data, want, copied)
+		}
+	}
+
+	if s := readCoreState(t, g.core); s.Stall != stall {
+		t.Errorf("stall = %d cycles, want %d", s.Stall, stall)
+	}
+
+	data, err := g.mmu.Read(AddrHDMA5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data != hdma5 {
+		t.Errorf("hdma5 = %02x, want %02x", data, hdma5)
+	}
+}
+
+// TestGeneralPurposeDMA copies all the blocks at once
+func TestGeneralPurposeDMA(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		dst         uint16
+		hdma5       byte
+		doubleSpeed bool
+		copied      int
+		stall       int32
+	}{
+		{"single block", 0x0000, 0x00, false, 16, 32},
+		{"three blocks", 0x0100, 0x02, false, 48, 96},
+		{"double speed", 0x0100, 0x02, true, 48, 192},
+		{"longest", 0x0000, 0x7F, false, 2048, 4096},
+		{"end of vram", 0x1FE0, 0x03, false, 32, 64},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			g := testHDMA(t, test.doubleSpeed)
+
+			startHDMA(t, g, 0xC000, test.dst, test.hdma5)
+			checkHDMA(t, g, test.dst, test.copied, test.stall, 0xFF)
+		})
+	}
+}
+
+// TestHBlankDMA copies a single block at every h-blank, the
+// transfer is cancelled by a write with bit 7 clear
+func TestHBlankDMA(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		hdma5   byte
+		hblanks int
+		cancel  bool
+		copied  int
+		stall   int32
+		status  byte // hdma5 at the end
+	}{
+		{"started", 0x83, 0, false, 0, 0, 0x03},
+		{"single h-blank", 0x83, 1, false, 16, 32, 0x02},
+		{"three h-blanks", 0x83, 3, false, 48, 96, 0x00},
+		{"completed", 0x83, 4, false, 64, 128, 0xFF},
+		{"after completion", 0x83, 6, false, 64, 128, 0xFF},
+		{"cancelled", 0x83, 1, true, 16, 32, 0x82},
+		{"cancelled and h-blanks", 0x83, 3, true, 48, 96, 0x80},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			g := testHDMA(t, false)
+
+			startHDMA(t, g, 0xC000, 0x0000, test.hdma5)
+
+			for i := 0; i < test.hblanks; i++ {
+
+				if err := g.hdma.hblank(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if test.cancel {
+
+				if err := g.mmu.Write(AddrHDMA5, 0x00); err != nil {
+					t.Fatal(err)
+				}
+
+				// nothing is copied after the transfer was cancelled
+				if err := g.hdma.hblank(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			checkHDMA(t, g, 0x0000, test.copied, test.stall, test.status)
+		})
+	}
+}
+
+// TestHBlankDMADisplayOff copies the first block right away
+// when the transfer starts with the display off
+func TestHBlankDMADisplayOff(t *testing.T) {
+
+	g := testHDMA(t, false)
+	g.lcdc = 0x11
+
+	startHDMA(t, g, 0xC000, 0x0000, 0x81)
+	checkHDMA(t, g, 0x0000, 16, 32, 0x00)
+}
@@ -0,0 +1,70 @@
+package display
+
+import (
+	"image"
+	"image/color"
+)
+
+// Image converts the frame to an image, 'colors' holds the
+// 0x00RRGGBB values of the 4 pixel colors and 'scale' is the
+// (integer) image scale
+func (f *Frame) Image(colors [4]uint32, scale int) *image.RGBA {
+
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, ScreenWidth*scale, ScreenHeight*scale))
+
+	for x := 0; x < ScreenWidth; x++ {
+		for y := 0; y < ScreenHeight; y++ {
+
+			c := rgba(f[x][y].RGB(colors))
+
+			for i := x * scale; i < (x+1)*scale; i++ {
+				for j := y * scale; j < (y+1)*scale; j++ {
+					img.SetRGBA(i, j, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// Image converts the border with the frame in its center to an
+// image (see Frame.Image)
+func (b *Border) Image(f *Frame, colors [4]uint32, scale int) *image.RGBA {
+
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, BorderWidth*scale, BorderHeight*scale))
+
+	for x := 0; x < BorderWidth; x++ {
+		for y := 0; y < BorderHeight; y++ {
+
+			p := b[x][y]
+
+			if fx, fy := x-BorderFrameX, y-BorderFrameY; 0 <= fx && fx < ScreenWidth && 0 <= fy && fy < ScreenHeight {
+				p = f[fx][fy]
+			}
+
+			c := rgba(p.RGB(colors))
+
+			for i := x * scale; i < (x+1)*scale; i++ {
+				for j := y * scale; j < (y+1)*scale; j++ {
+					img.SetRGBA(i, j, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// rgba converts 0x00RRGGBB to an opaque color
+func rgba(c uint32) color.RGBA {
+	return color.RGBA{R: byte(c >> 16), G: byte(c >> 8), B: byte(c), A: 0xFF}
+}
@@ -0,0 +1,57 @@
+package display
+
+// MemoryMonitor is a Monitor that keeps the last drawn frame in memory
+type MemoryMonitor struct {
+	frame   Frame
+	border  *Border
+	frames  int
+	onFrame func(count int)
+}
+
+// NewMemoryMonitor creates MemoryMonitor instance, 'onFrame' (optional)
+// is called after every drawn frame with the number of frames so far
+func NewMemoryMonitor(onFrame func(count int)) *MemoryMonitor {
+	return &MemoryMonitor{onFrame: onFrame}
+}
+
+// Close does nothing
+func (m *MemoryMonitor) Close() error {
+	return nil
+}
+
+// DrawFrame keeps a copy of the frame
+func (m *MemoryMonitor) DrawFrame(f *Frame) error {
+
+	m.frame = *f
+	m.frames++
+
+	if m.onFrame != nil {
+		m.onFrame(m.frames)
+	}
+
+	return nil
+}
+
+// DrawBorder keeps a copy of the border
+func (m *MemoryMonitor) DrawBorder(b *Border) error {
+
+	border := *b
+	m.border = &border
+
+	return nil
+}
+
+// Border returns the last drawn border (or nil)
+func (m *MemoryMonitor) Border() *Border {
+	return m.border
+}
+
+// LastFrame returns the last drawn frame
+func (m *MemoryMonitor) LastFrame() *Frame {
+	return &m.frame
+}
+
+// Frames returns the number of frames drawn so far
+func (m *MemoryMonitor) Frames() int {
+	return m.frames
+}
@@ -1,7 +1,8 @@
 package display
 
-// Pixel represents a single pixel color in the monitor
-type Pixel byte
+// Pixel represents a single pixel color in the monitor, either a
+// shade (PixelWhite - PixelBlack) or an RGB555 color (with PixelRGB)
+type Pixel uint16
 
 // PixelWhite is color 0
 const PixelWhite Pixel = 0
@@ -15,6 +16,35 @@ const PixelDarkGray Pixel = 2
 // PixelBlack is color 3 (darkest)
 const PixelBlack Pixel = 3
 
+// PixelRGB marks a pixel that holds an RGB555 color in bits 0 - 14
+const PixelRGB Pixel = 0x8000
+
+// RGB555 returns the pixel of an RGB555 color (red in bits 0 - 4)
+func RGB555(c uint16) Pixel {
+	return PixelRGB | Pixel(c&0x7FFF)
+}
+
+// RGB converts the pixel to 0x00RRGGBB, 'colors' holds the
+// 0x00RRGGBB values of the 4 shades
+func (p Pixel) RGB(colors [4]uint32) uint32 {
+
+	if p&PixelRGB == 0 {
+
+		if p > PixelBlack {
+			return 0xFFFFFF
+		}
+
+		return colors[p]
+	}
+
+	// expand each 5 bits component to 8 bits
+	r := uint32(p) & 0x1F
+	g := (uint32(p) >> 5) & 0x1F
+	b := (uint32(p) >> 10) & 0x1F
+
+	return (r<<3|r>>2)<<16 | (g<<3|g>>2)<<8 | (b<<3 | b>>2)
+}
+
 // ScreenWidth is the physical screen width
 const ScreenWidth int = 160
 
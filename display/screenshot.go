@@ -0,0 +1,82 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoFrame is returned when a screenshot is requested before the first frame
+var ErrNoFrame = errors.New("ErrNoFrame")
+
+// LastFrame returns the last frame that was sent to the monitor (or nil)
+func (g *GPU) LastFrame() *Frame {
+
+	var f *Frame
+
+	g.core.Exec(func() error {
+		f = g.last
+		return nil
+	})
+
+	return f
+}
+
+// WritePNG writes the last frame to 'w' as a png image, 'colors' holds
+// the 0x00RRGGBB values of the 4 pixel colors and 'scale' is the
+// (integer) image scale
+func (g *GPU) WritePNG(w io.Writer, colors [4]uint32, scale int) error {
+
+	f := g.LastFrame()
+
+	if f == nil {
+		return ErrNoFrame
+	}
+
+	return png.Encode(w, f.Image(colors, scale))
+}
+
+// Screenshot writes the last frame to a png file in 'dir', named after
+// 'title' and the current time, returns the file name
+func (g *GPU) Screenshot(dir, title string, colors [4]uint32, scale int) (string, error) {
+
+	if g.LastFrame() == nil {
+		return "", ErrNoFrame
+	}
+
+	file := FileName(dir, title, "screenshot", ".png")
+
+	f, err := os.Create(file)
+
+	if err != nil {
+		return file, err
+	}
+
+	defer f.Close()
+
+	return file, g.WritePNG(f, colors, scale)
+}
+
+// FileName returns a file name in 'dir', named after 'title' (or 'fallback'
+// when the title is empty) and the current time, with the extension 'ext'
+func FileName(dir, title, fallback, ext string) string {
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(title))
+
+	if len(name) == 0 {
+		name = fallback
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", name, time.Now().Format("20060102-150405.000"), ext))
+}
@@ -15,22 +15,6 @@ const ObjPalette1 byte = 1
 // SpriteAttr from OAM
 type SpriteAttr [4]byte
 
-// SpriteAttrs from OAM
-type SpriteAttrs []*SpriteAttr
-
-func (s SpriteAttrs) Len() int {
-	return len(s)
-}
-
-func (s SpriteAttrs) Less(i, j int) bool {
-	return s[i].coordinateX() < s[j].coordinateX() ||
-		(s[i].coordinateX() == s[j].coordinateX() && s[i].tileID() < s[j].tileID())
-}
-
-func (s SpriteAttrs) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
-}
-
 func (s *SpriteAttr) coordinateY() byte {
 	return s[0]
 }
@@ -58,3 +42,13 @@ func (s *SpriteAttr) flipX() bool {
 func (s *SpriteAttr) palette() byte {
 	return (s[3] << 3) >> 7
 }
+
+// bank returns the vram bank of the tile (cgb)
+func (s *SpriteAttr) bank() byte {
+	return (s[3] >> 3) & 0x01
+}
+
+// colorPalette returns the sprite palette number (cgb)
+func (s *SpriteAttr) colorPalette() byte {
+	return s[3] & 0x07
+}
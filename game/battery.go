@@ -0,0 +1,162 @@
+package game
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+)
+
+// FlushRate is the number of cpu cycles between two
+// checks for unsaved changes in the battery backed ram
+const FlushRate int = 5 * cpu.Frequency
+
+// hasBattery returns true iff the cartridge type includes a battery
+func hasBattery(mbcType byte) bool {
+
+	switch mbcType {
+	case 0x03, 0x06, 0x0F, 0x10, 0x13, 0x1B, 0x1E:
+		return true
+	}
+
+	return false
+}
+
+// saveFileName returns the .sav file name for the given rom file
+func saveFileName(fileROM string) string {
+	return strings.TrimSuffix(fileROM, filepath.Ext(fileROM)) + ".sav"
+}
+
+// loadBattery reads the ram content from the .sav file (if exists)
+func (c *Cartridge) loadBattery() error {
+
+	data, err := ioutil.ReadFile(c.saveFile)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	copy(c.ramData, data)
+
+	// the rtc footer follows the ram
+	if c.rtc != nil && len(data) > len(c.ramData) {
+		c.rtc.loadRTC(data[len(c.ramData):], time.Now())
+	}
+
+	return nil
+}
+
+// OnSaveError sets the function that is called when a background
+// write of the .sav file fails, the write is retried on the next period
+func (c *Cartridge) OnSaveError(fn func(err error)) {
+	c.onSaveError = fn
+}
+
+// Flush the battery backed ram (and rtc) to the .sav file, waits
+// for a background write that is in progress
+func (c *Cartridge) Flush() error {
+
+	c.saving.Wait()
+
+	// the rtc timestamp is always refreshed
+	if !c.saveable() || !(c.dirty || c.rtc != nil || atomic.LoadInt32(&c.saveFailed) != 0) {
+		return nil
+	}
+
+	if err := c.writeSave(c.saveData()); err != nil {
+		return err
+	}
+
+	c.dirty = false
+	atomic.StoreInt32(&c.saveFailed, 0)
+
+	return nil
+}
+
+// saveable returns true iff there is battery backed ram (or rtc) to save
+func (c *Cartridge) saveable() bool {
+	return c.battery && (len(c.ramData) > 0 || c.rtc != nil)
+}
+
+// saveData returns a copy of the ram content (and the rtc footer)
+func (c *Cartridge) saveData() []byte {
+
+	data := append([]byte(nil), c.ramData...)
+
+	if c.rtc != nil {
+		data = append(data, c.rtc.saveRTC(time.Now())...)
+	}
+
+	return data
+}
+
+// writeSave writes 'data' to the .sav file
+func (c *Cartridge) writeSave(data []byte) error {
+
+	// write to a temporary file first, so a failure
+	// won't leave a truncated save file behind
+	tmp := c.saveFile + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.saveFile)
+}
+
+// ClockChanged is called after every instruction execution, the changed
+// ram is written in the background, a failed write is reported to the
+// save error handler and retried on the next period
+func (c *Cartridge) ClockChanged(cycles int) error {
+
+	c.cyclesCounter += cycles
+
+	if c.cyclesCounter < FlushRate {
+		return nil
+	}
+
+	c.cyclesCounter = c.cyclesCounter - FlushRate
+
+	if atomic.SwapInt32(&c.saveFailed, 0) != 0 {
+		c.dirty = true
+	}
+
+	if !c.dirty || !c.saveable() {
+		return nil
+	}
+
+	// the previous write is still in progress
+	if !atomic.CompareAndSwapInt32(&c.writing, 0, 1) {
+		return nil
+	}
+
+	data := c.saveData()
+	c.dirty = false
+	c.saving.Add(1)
+
+	go func() {
+
+		defer c.saving.Done()
+		defer atomic.StoreInt32(&c.writing, 0)
+
+		if err := c.writeSave(data); err != nil {
+
+			atomic.StoreInt32(&c.saveFailed, 1)
+
+			if c.onSaveError != nil {
+				c.onSaveError(fmt.Errorf("battery save failed - %s", err))
+			}
+		}
+	}()
+
+	return nil
+}
@@ -1,8 +1,11 @@
 package game
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"io/ioutil"
+	"sync"
 
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/memory"
@@ -13,9 +16,29 @@ import (
 // ErrCorrupted is returned when the loaded ROM is corrupted
 var ErrCorrupted = errors.New("ErrCorrupted")
 
+// controller is a memory bank controller
+type controller interface {
+	memory.Unit
+	ROMBank() int
+	SaveState(w io.Writer) error
+	LoadState(r io.Reader) error
+}
+
 // Cartridge represents the GB Classic game cartridge
 type Cartridge struct {
-	mbc memory.Unit
+	mbc           controller
+	header        *Header
+	romSize       int
+	rtc           *MBC3
+	ramData       []byte
+	battery       bool
+	saveFile      string
+	dirty         bool
+	cyclesCounter int
+	saving        sync.WaitGroup // the background write of the .sav file
+	writing       int32          // a background write is in progress (accessed atomically)
+	saveFailed    int32          // the last background write failed (accessed atomically)
+	onSaveError   func(err error)
 }
 
 // NewCartridge creates Cartridge instance
@@ -28,41 +51,41 @@ func NewCartridge(fileROM string, core *cpu.Core) (*Cartridge, error) {
 		return nil, err
 	}
 
-	rom := memory.NewROM(romData, 0)
+	header, err := ParseHeader(romData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the rom must hold (at least) the banks declared in the header
+	if romSize := header.ROMBytes(); romSize < 0 || len(romData) < romSize {
+		return nil, fmt.Errorf("rom size mismatch (header: %02x, file: %d bytes)", header.ROMSize, len(romData))
+	}
 
 	var ramData []byte
 
-	// read ram type
-	if ramType, err := rom.Read(0x0149); err == nil {
-
-		// alocate ram
-		switch ramType {
-
-		case 0x00: // No RAM or MBC2
-			break
-		case 0x01: // 2 KBytes
-			ramData = make([]byte, 2048)
-		case 0x02: // 8 Kbytes
-			ramData = make([]byte, 8192)
-		case 0x03: //32 KBytes
-			ramData = make([]byte, 32768)
-		default:
-			return nil, ErrCorrupted
-		}
+	// alocate ram
+	switch ramSize := header.RAMBytes(); {
 
-	} else {
-		// failed to read ram type
-		return nil, err
+	case ramSize < 0:
+		return nil, ErrCorrupted
+	case ramSize > 0:
+		ramData = make([]byte, ramSize)
 	}
 
-	// read MBC type
-	mbcType, err := rom.Read(0x0147)
+	mbcType := header.CartridgeType
 
-	if err != nil {
-		return nil, err
+	// mbc2 has a built-in 512x4 bits ram
+	if mbcType == 0x05 || mbcType == 0x06 {
+		ramData = make([]byte, 512)
 	}
 
-	c := Cartridge{}
+	c := Cartridge{
+		header:   header,
+		romSize:  len(romData),
+		ramData:  ramData,
+		battery:  hasBattery(mbcType),
+		saveFile: saveFileName(fileROM)}
 
 	// create MBC
 	switch mbcType {
@@ -77,22 +100,54 @@ func NewCartridge(fileROM string, core *cpu.Core) (*Cartridge, error) {
 
 	case 0x05, 0x06: // MBC2
 
-		c.mbc = NewMBC2(romData)
+		c.mbc = NewMBC2(romData, ramData)
 
 	case 0x0F, 0x10, 0x11, 0x12, 0x13: // MBC3
 
 		mbc3 := NewMBC3(romData, ramData)
-		core.RegisterToClockChanges(mbc3)
+		core.RegisterToFixedClockChanges(mbc3)
 		c.mbc = mbc3
 
+		if mbcType == 0x0F || mbcType == 0x10 {
+			c.rtc = mbc3
+		}
+
+	case 0x19, 0x1A, 0x1B: // MBC5
+
+		c.mbc = NewMBC5(romData, ramData, false)
+
+	case 0x1C, 0x1D, 0x1E: // MBC5 + rumble
+
+		c.mbc = NewMBC5(romData, ramData, true)
+
 	default:
 
 		return nil, fmt.Errorf("cartridge type not supported (%x)", mbcType)
 	}
 
+	// load battery backed ram (and rtc)
+	if c.battery {
+
+		if err := c.loadBattery(); err != nil {
+			return nil, err
+		}
+
+		core.RegisterToFixedClockChanges(&c)
+	}
+
 	return &c, nil
 }
 
+// Header returns the decoded cartridge header
+func (c *Cartridge) Header() *Header {
+	return c.header
+}
+
+// ROMSize returns the actual rom size in bytes
+func (c *Cartridge) ROMSize() int {
+	return c.romSize
+}
+
 // Read from address 'addr'
 func (c *Cartridge) Read(addr uint16) (byte, error) {
 	return c.mbc.Read(addr)
@@ -100,5 +155,88 @@ func (c *Cartridge) Read(addr uint16) (byte, error) {
 
 // Write 'data' to address 'addr'
 func (c *Cartridge) Write(addr uint16, data byte) error {
+
+	if c.battery && 0xA000 <= addr && addr <= 0xBFFF {
+		return c.writeBacked(addr, data)
+	}
+
 	return c.mbc.Write(addr, data)
 }
+
+// writeBacked writes to the battery backed ram (or rtc), the cartridge
+// is marked dirty only if the write changed the ram or the rtc (a write
+// while the ram is disabled is ignored by the mbc)
+func (c *Cartridge) writeBacked(addr uint16, data byte) error {
+
+	before, err := c.mbc.Read(addr)
+
+	if err != nil {
+		return c.mbc.Write(addr, data)
+	}
+
+	var rtc [5]byte
+
+	if c.rtc != nil {
+		rtc = c.rtc.rtc
+	}
+
+	if err := c.mbc.Write(addr, data); err != nil {
+		return err
+	}
+
+	if after, err := c.mbc.Read(addr); err != nil || after != before {
+		c.dirty = true
+	}
+
+	if c.rtc != nil && c.rtc.rtc != rtc {
+		c.dirty = true
+	}
+
+	return nil
+}
+
+// OnRumble sets the function that is called when the rumble motor
+// is turned on or off (only for cartridges with a rumble motor)
+func (c *Cartridge) OnRumble(fn func(on bool)) {
+
+	if mbc5, ok := c.mbc.(*MBC5); ok && mbc5.hasRumble {
+		mbc5.OnRumble(fn)
+	}
+}
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (c *Cartridge) ROMBank() int {
+	return c.mbc.ROMBank()
+}
+
+// SaveState writes the MBC registers and ram to 'w'
+func (c *Cartridge) SaveState(w io.Writer) error {
+	return c.mbc.SaveState(w)
+}
+
+// LoadState reads the MBC registers and ram from 'r', the cartridge
+// is marked dirty only if the loaded ram or rtc differ
+func (c *Cartridge) LoadState(r io.Reader) error {
+
+	if !c.battery {
+		return c.mbc.LoadState(r)
+	}
+
+	ram := append([]byte(nil), c.ramData...)
+
+	var rtc [5]byte
+
+	if c.rtc != nil {
+		rtc = c.rtc.rtc
+	}
+
+	if err := c.mbc.LoadState(r); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(ram, c.ramData) || (c.rtc != nil && c.rtc.rtc != rtc) {
+		c.dirty = true
+	}
+
+	return nil
+}
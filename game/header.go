@@ -0,0 +1,221 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrHeaderTooShort is returned when the ROM is too short to contain a header
+var ErrHeaderTooShort = errors.New("ErrHeaderTooShort")
+
+// HeaderSize is the size of the ROM area that ends with the header (0000-014F)
+const HeaderSize int = 0x0150
+
+// Header is the cartridge header (0100-014F)
+type Header struct {
+	Title            string // 0134-0143 (or 0134-013E for cgb games)
+	TitleBytes       []byte // 0134-0143 (untrimmed)
+	ManufacturerCode string // 013F-0142 (cgb games only)
+	CGBFlag          byte   // 0143
+	NewLicenseeCode  string // 0144-0145
+	SGBFlag          byte   // 0146
+	CartridgeType    byte   // 0147
+	ROMSize          byte   // 0148
+	RAMSize          byte   // 0149
+	DestinationCode  byte   // 014A
+	OldLicenseeCode  byte   // 014B
+	Version          byte   // 014C
+	HeaderChecksum   byte   // 014D
+	GlobalChecksum   uint16 // 014E-014F
+
+	computedHeaderChecksum byte
+	computedGlobalChecksum uint16
+}
+
+// ParseHeader decodes the header of the given rom
+func ParseHeader(rom []byte) (*Header, error) {
+
+	if len(rom) < HeaderSize {
+		return nil, ErrHeaderTooShort
+	}
+
+	h := Header{
+		TitleBytes:      append([]byte(nil), rom[0x0134:0x0144]...),
+		CGBFlag:         rom[0x0143],
+		NewLicenseeCode: string(rom[0x0144:0x0146]),
+		SGBFlag:         rom[0x0146],
+		CartridgeType:   rom[0x0147],
+		ROMSize:         rom[0x0148],
+		RAMSize:         rom[0x0149],
+		DestinationCode: rom[0x014A],
+		OldLicenseeCode: rom[0x014B],
+		Version:         rom[0x014C],
+		HeaderChecksum:  rom[0x014D],
+		GlobalChecksum:  uint16(rom[0x014E])<<8 | uint16(rom[0x014F])}
+
+	// newer (cgb) games use the last 5 title bytes for the
+	// manufacturer code and the cgb flag
+	if h.CGBFlag&0x80 == 0x80 {
+		h.Title = headerString(rom[0x0134:0x013F])
+		h.ManufacturerCode = headerString(rom[0x013F:0x0143])
+	} else {
+		h.Title = headerString(rom[0x0134:0x0144])
+	}
+
+	for addr := 0x0134; addr <= 0x014C; addr++ {
+		h.computedHeaderChecksum = h.computedHeaderChecksum - rom[addr] - 1
+	}
+
+	for addr, b := range rom {
+		if addr != 0x014E && addr != 0x014F {
+			h.computedGlobalChecksum += uint16(b)
+		}
+	}
+
+	return &h, nil
+}
+
+// headerString trims the zero padding of a header string
+func headerString(b []byte) string {
+
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// CGBSupported returns true iff the game supports the cgb functions
+func (h *Header) CGBSupported() bool {
+	return h.CGBFlag&0x80 == 0x80
+}
+
+// CGBOnly returns true iff the game works on the cgb only
+func (h *Header) CGBOnly() bool {
+	return h.CGBFlag == 0xC0
+}
+
+// SGBSupported returns true iff the game supports the sgb functions
+func (h *Header) SGBSupported() bool {
+	return h.SGBFlag == 0x03 && h.OldLicenseeCode == 0x33
+}
+
+// ROMBytes returns the rom size in bytes, according to the header (or -1)
+func (h *Header) ROMBytes() int {
+
+	switch h.ROMSize {
+	case 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08:
+		return 32768 << h.ROMSize
+	case 0x52:
+		return 72 * 16384
+	case 0x53:
+		return 80 * 16384
+	case 0x54:
+		return 96 * 16384
+	}
+
+	return -1
+}
+
+// RAMBytes returns the external ram size in bytes, according to the header (or -1)
+func (h *Header) RAMBytes() int {
+
+	switch h.RAMSize {
+	case 0x00:
+		return 0
+	case 0x01:
+		return 2048
+	case 0x02:
+		return 8192
+	case 0x03:
+		return 32768
+	case 0x04:
+		return 131072
+	case 0x05:
+		return 65536
+	}
+
+	return -1
+}
+
+// Destination returns the destination name
+func (h *Header) Destination() string {
+
+	if h.DestinationCode == 0x00 {
+		return "Japanese"
+	}
+
+	return "Non-Japanese"
+}
+
+// Licensee returns the licensee (publisher) name
+func (h *Header) Licensee() string {
+
+	if h.OldLicenseeCode == 0x33 {
+
+		if name, found := newLicensees[h.NewLicenseeCode]; found {
+			return name
+		}
+
+		return fmt.Sprintf("Unknown (%s)", h.NewLicenseeCode)
+	}
+
+	if name, found := oldLicensees[h.OldLicenseeCode]; found {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%02x)", h.OldLicenseeCode)
+}
+
+// CartridgeTypeName returns the cartridge type (mbc and additional hardware)
+func (h *Header) CartridgeTypeName() string {
+
+	if name, found := cartridgeTypes[h.CartridgeType]; found {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%02x)", h.CartridgeType)
+}
+
+// HeaderChecksumValid returns true iff the header checksum matches the header bytes
+func (h *Header) HeaderChecksumValid() bool {
+	return h.HeaderChecksum == h.computedHeaderChecksum
+}
+
+// GlobalChecksumValid returns true iff the global checksum matches the rom bytes
+// (the boot rom does not verify this one)
+func (h *Header) GlobalChecksumValid() bool {
+	return h.GlobalChecksum == h.computedGlobalChecksum
+}
+
+// cartridgeTypes names by code (0147)
+var cartridgeTypes = map[byte]string{
+	0x00: "ROM ONLY",
+	0x01: "MBC1",
+	0x02: "MBC1+RAM",
+	0x03: "MBC1+RAM+BATTERY",
+	0x05: "MBC2",
+	0x06: "MBC2+BATTERY",
+	0x08: "ROM+RAM",
+	0x09: "ROM+RAM+BATTERY",
+	0x0B: "MMM01",
+	0x0C: "MMM01+RAM",
+	0x0D: "MMM01+RAM+BATTERY",
+	0x0F: "MBC3+TIMER+BATTERY",
+	0x10: "MBC3+TIMER+RAM+BATTERY",
+	0x11: "MBC3",
+	0x12: "MBC3+RAM",
+	0x13: "MBC3+RAM+BATTERY",
+	0x19: "MBC5",
+	0x1A: "MBC5+RAM",
+	0x1B: "MBC5+RAM+BATTERY",
+	0x1C: "MBC5+RUMBLE",
+	0x1D: "MBC5+RUMBLE+RAM",
+	0x1E: "MBC5+RUMBLE+RAM+BATTERY",
+	0x20: "MBC6",
+	0x22: "MBC7+SENSOR+RUMBLE+RAM+BATTERY",
+	0xFC: "POCKET CAMERA",
+	0xFD: "BANDAI TAMA5",
+	0xFE: "HuC3",
+	0xFF: "HuC1+RAM+BATTERY"}
@@ -1,6 +1,20 @@
 package game
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// mbc1State is the serialized form of the mbc1 registers
+type mbc1State struct {
+	Mode      byte
+	BankRAM   uint32
+	BankROM0  uint32
+	BankROM1  uint32
+	EnableRAM bool
+}
 
 // MBC1 (max 2MByte ROM and/or 32KByte RAM)
 type MBC1 struct {
@@ -142,3 +156,48 @@ func (m *MBC1) Write(addr uint16, data byte) error {
 
 	return memory.WriteOutOfRangeError(addr)
 }
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (m *MBC1) ROMBank() int {
+
+	if m.mode == 0 {
+		return int(m.bankROM0)
+	}
+
+	return int(m.bankROM1)
+}
+
+// SaveState writes the mbc1 registers and ram to 'w'
+func (m *MBC1) SaveState(w io.Writer) error {
+
+	s := mbc1State{
+		Mode:      m.mode,
+		BankRAM:   m.bankRAM,
+		BankROM0:  m.bankROM0,
+		BankROM1:  m.bankROM1,
+		EnableRAM: m.enableRAM}
+
+	if err := binary.Write(w, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	return m.ram.SaveState(w)
+}
+
+// LoadState reads the mbc1 registers and ram from 'r'
+func (m *MBC1) LoadState(r io.Reader) error {
+
+	var s mbc1State
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	m.mode = s.Mode
+	m.bankRAM = s.BankRAM
+	m.bankROM0 = s.BankROM0
+	m.bankROM1 = s.BankROM1
+	m.enableRAM = s.EnableRAM
+
+	return m.ram.LoadState(r)
+}
@@ -1,6 +1,17 @@
 package game
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// mbc2State is the serialized form of the mbc2 registers
+type mbc2State struct {
+	BankROM   uint32
+	EnableRAM bool
+}
 
 // MBC2 (max 256KByte ROM and 512x4 bits RAM)
 type MBC2 struct {
@@ -11,13 +22,14 @@ type MBC2 struct {
 	enableRAM  bool
 }
 
-// NewMBC2 creates mbc2 instance
-func NewMBC2(rom []byte) *MBC2 {
+// NewMBC2 creates mbc2 instance ('ram' is the 512 bytes built-in ram,
+// only the lower 4 bits of every byte are used)
+func NewMBC2(rom []byte, ram []byte) *MBC2 {
 
 	m := MBC2{
 		rom:        memory.NewROM(rom, 0x0000),
 		otherBanks: memory.NewROM(rom, 0x4000),
-		ram:        memory.NewRAM(make([]byte, 512), 0xA000),
+		ram:        memory.NewRAM(ram, 0xA000),
 		bankROM:    1}
 
 	return &m
@@ -107,3 +119,35 @@ func (m *MBC2) Write(addr uint16, data byte) error {
 
 	return memory.WriteOutOfRangeError(addr)
 }
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (m *MBC2) ROMBank() int {
+	return int(m.bankROM)
+}
+
+// SaveState writes the mbc2 registers and ram to 'w'
+func (m *MBC2) SaveState(w io.Writer) error {
+
+	s := mbc2State{BankROM: m.bankROM, EnableRAM: m.enableRAM}
+
+	if err := binary.Write(w, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	return m.ram.SaveState(w)
+}
+
+// LoadState reads the mbc2 registers and ram from 'r'
+func (m *MBC2) LoadState(r io.Reader) error {
+
+	var s mbc2State
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	m.bankROM = s.BankROM
+	m.enableRAM = s.EnableRAM
+
+	return m.ram.LoadState(r)
+}
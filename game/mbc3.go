@@ -3,7 +3,29 @@ package game
 import "github.com/moshenahmias/gopherboy/memory"
 import "github.com/moshenahmias/gopherboy/cpu"
 
-import "time"
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// rtcFooterSize is the size of the rtc footer that follows the ram in the
+// save file: 5 rtc registers, 5 latched rtc registers (4 bytes each, little
+// endian) and a 64 bit unix timestamp (some emulators write a 32 bit one)
+const rtcFooterSize = 48
+
+// mbc3State is the serialized form of the mbc3 registers
+type mbc3State struct {
+	BankRAM           uint32
+	BankROM           uint32
+	EnableTimerAndRAM bool
+	RTCCode           byte
+	RTC               [5]byte
+	Latched           bool
+	RTCSnapshot       [5]byte
+	Latch             bool
+	CyclesCounter     int32
+}
 
 // MBC3 (max 2MByte ROM and/or 32KByte RAM and Timer)
 type MBC3 struct {
@@ -34,7 +56,6 @@ func NewMBC3(rom []byte, ram []byte) *MBC3 {
 
 	now := time.Now()
 
-	m.setDays(uint16(now.Day()))
 	m.setHours(byte(now.Hour()))
 	m.setMinutes(byte(now.Minute()))
 	m.setSeconds(byte(now.Second()))
@@ -83,6 +104,79 @@ func (m *MBC3) setDayCarry() {
 	m.rtc[4] |= 0x80
 }
 
+// advance the rtc by the given number of seconds (unless halted)
+func (m *MBC3) advance(seconds int64) {
+
+	if m.halt() || seconds <= 0 {
+		return
+	}
+
+	total := int64(m.seconds()) +
+		int64(m.minutes())*60 +
+		int64(m.hours())*3600 +
+		int64(m.days())*86400 +
+		seconds
+
+	days := total / 86400
+
+	if days > 511 {
+		m.setDayCarry()
+		days %= 512
+	}
+
+	m.setDays(uint16(days))
+	m.setHours(byte((total % 86400) / 3600))
+	m.setMinutes(byte((total % 3600) / 60))
+	m.setSeconds(byte(total % 60))
+}
+
+// saveRTC returns the rtc footer for the save file
+func (m *MBC3) saveRTC(now time.Time) []byte {
+
+	footer := make([]byte, rtcFooterSize)
+
+	latched := m.rtc[:]
+
+	if m.rtcSnapshot != nil {
+		latched = m.rtcSnapshot
+	}
+
+	for i := 0; i < 5; i++ {
+		binary.LittleEndian.PutUint32(footer[i*4:], uint32(m.rtc[i]))
+		binary.LittleEndian.PutUint32(footer[20+i*4:], uint32(latched[i]))
+	}
+
+	binary.LittleEndian.PutUint64(footer[40:], uint64(now.Unix()))
+
+	return footer
+}
+
+// loadRTC from the save file footer and advance the clock
+// by the wall time that passed since it was saved, the latched
+// registers are ignored so the game's next latch sequence
+// latches the live clock instead of toggling the latch off
+func (m *MBC3) loadRTC(footer []byte, now time.Time) {
+
+	var timestamp int64
+
+	switch {
+	case len(footer) >= rtcFooterSize:
+		timestamp = int64(binary.LittleEndian.Uint64(footer[40:]))
+	case len(footer) >= rtcFooterSize-4:
+		timestamp = int64(binary.LittleEndian.Uint32(footer[40:]))
+	default:
+		return
+	}
+
+	m.rtcSnapshot = nil
+
+	for i := 0; i < 5; i++ {
+		m.rtc[i] = byte(binary.LittleEndian.Uint32(footer[i*4:]))
+	}
+
+	m.advance(now.Unix() - timestamp)
+}
+
 // ClockChanged is called after every instruction execution
 func (m *MBC3) ClockChanged(cycles int) error {
 
@@ -282,3 +376,56 @@ func (m *MBC3) Write(addr uint16, data byte) error {
 
 	return memory.WriteOutOfRangeError(addr)
 }
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (m *MBC3) ROMBank() int {
+	return int(m.bankROM)
+}
+
+// SaveState writes the mbc3 registers, rtc and ram to 'w'
+func (m *MBC3) SaveState(w io.Writer) error {
+
+	s := mbc3State{
+		BankRAM:           m.bankRAM,
+		BankROM:           m.bankROM,
+		EnableTimerAndRAM: m.enableTimerAndRAM,
+		RTCCode:           m.rtcCode,
+		RTC:               m.rtc,
+		Latched:           m.rtcSnapshot != nil,
+		Latch:             m.latch,
+		CyclesCounter:     int32(m.cyclesCounter)}
+
+	copy(s.RTCSnapshot[:], m.rtcSnapshot)
+
+	if err := binary.Write(w, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	return m.ram.SaveState(w)
+}
+
+// LoadState reads the mbc3 registers, rtc and ram from 'r'
+func (m *MBC3) LoadState(r io.Reader) error {
+
+	var s mbc3State
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	m.bankRAM = s.BankRAM
+	m.bankROM = s.BankROM
+	m.enableTimerAndRAM = s.EnableTimerAndRAM
+	m.rtcCode = s.RTCCode
+	m.rtc = s.RTC
+	m.latch = s.Latch
+	m.cyclesCounter = int(s.CyclesCounter)
+	m.rtcSnapshot = nil
+
+	if s.Latched {
+		m.rtcSnapshot = make([]byte, 5)
+		copy(m.rtcSnapshot, s.RTCSnapshot[:])
+	}
+
+	return m.ram.LoadState(r)
+}
@@ -0,0 +1,206 @@
+package game
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// mbc5State is the serialized form of the mbc5 registers
+type mbc5State struct {
+	BankRAM   uint32
+	BankROM   uint32
+	EnableRAM bool
+	Motor     bool
+}
+
+// MBC5 (max 8MByte ROM and/or 128KByte RAM, optional rumble motor)
+type MBC5 struct {
+	rom        *memory.ROM
+	otherBanks *memory.ROM
+	ram        *memory.RAM
+	banksROM   uint32
+	banksRAM   uint32
+	bankRAM    uint32
+	bankROM    uint32
+	enableRAM  bool
+	hasRumble  bool
+	motor      bool
+	rumble     func(on bool)
+}
+
+// NewMBC5 creates mbc5 instance
+func NewMBC5(rom []byte, ram []byte, hasRumble bool) *MBC5 {
+
+	m := MBC5{
+		rom:        memory.NewROM(rom, 0x0000),
+		otherBanks: memory.NewROM(rom, 0x4000),
+		ram:        memory.NewRAM(ram, 0xA000),
+		banksROM:   uint32(len(rom) / 16384),
+		banksRAM:   uint32(len(ram) / 8192),
+		bankROM:    1,
+		hasRumble:  hasRumble}
+
+	if m.banksROM == 0 {
+		m.banksROM = 1
+	}
+
+	return &m
+}
+
+// OnRumble sets the function that is called when the rumble motor state changes
+func (m *MBC5) OnRumble(fn func(on bool)) {
+	m.rumble = fn
+}
+
+// setMotor state and notify the rumble callback on changes
+func (m *MBC5) setMotor(on bool) {
+
+	if m.motor == on {
+		return
+	}
+
+	m.motor = on
+
+	if m.rumble != nil {
+		m.rumble(on)
+	}
+}
+
+// Read from address 'addr' at the target bank
+func (m *MBC5) Read(addr uint16) (byte, error) {
+
+	// rom bank 0
+	if 0x0000 <= addr && addr <= 0x3FFF {
+
+		if err := m.rom.SetWindow(0); err != nil {
+			return 0, err
+		}
+
+		return m.rom.Read(addr)
+	}
+
+	// other rom banks (bank 0 can be selected as well)
+	if 0x4000 <= addr && addr <= 0x7FFF {
+
+		if err := m.otherBanks.SetWindow((m.bankROM % m.banksROM) * 16384); err != nil {
+			return 0, err
+		}
+
+		return m.otherBanks.Read(addr)
+	}
+
+	// ram
+	if 0xA000 <= addr && addr <= 0xBFFF {
+
+		if !m.enableRAM || m.banksRAM == 0 {
+			return 0xFF, nil
+		}
+
+		if err := m.ram.SetWindow((m.bankRAM % m.banksRAM) * 8192); err != nil {
+			return 0, err
+		}
+
+		return m.ram.Read(addr)
+	}
+
+	return 0, memory.ReadOutOfRangeError(addr)
+}
+
+// Write 'data' to address 'addr' at the target bank
+// or change the MBC control registers
+func (m *MBC5) Write(addr uint16, data byte) error {
+
+	// enable ram
+	if 0x0000 <= addr && addr <= 0x1FFF {
+		m.enableRAM = data&0x0F == 0x0A
+		return nil
+	}
+
+	// rom bank (low 8 bits)
+	if 0x2000 <= addr && addr <= 0x2FFF {
+		m.bankROM = (m.bankROM & 0x100) | uint32(data)
+		return nil
+	}
+
+	// rom bank (9th bit)
+	if 0x3000 <= addr && addr <= 0x3FFF {
+		m.bankROM = (m.bankROM & 0x0FF) | (uint32(data&0x01) << 8)
+		return nil
+	}
+
+	// ram bank (and rumble motor)
+	if 0x4000 <= addr && addr <= 0x5FFF {
+
+		if m.hasRumble {
+
+			m.bankRAM = uint32(data & 0x07)
+			m.setMotor(data&0x08 == 0x08)
+
+		} else {
+
+			m.bankRAM = uint32(data & 0x0F)
+		}
+
+		return nil
+	}
+
+	if 0x6000 <= addr && addr <= 0x7FFF {
+		return nil
+	}
+
+	// ram
+	if 0xA000 <= addr && addr <= 0xBFFF {
+
+		if !m.enableRAM || m.banksRAM == 0 {
+			return nil
+		}
+
+		if err := m.ram.SetWindow((m.bankRAM % m.banksRAM) * 8192); err != nil {
+			return err
+		}
+
+		return m.ram.Write(addr, data)
+	}
+
+	return memory.WriteOutOfRangeError(addr)
+}
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (m *MBC5) ROMBank() int {
+	return int(m.bankROM % m.banksROM)
+}
+
+// SaveState writes the mbc5 registers and ram to 'w'
+func (m *MBC5) SaveState(w io.Writer) error {
+
+	s := mbc5State{
+		BankRAM:   m.bankRAM,
+		BankROM:   m.bankROM,
+		EnableRAM: m.enableRAM,
+		Motor:     m.motor}
+
+	if err := binary.Write(w, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	return m.ram.SaveState(w)
+}
+
+// LoadState reads the mbc5 registers and ram from 'r'
+func (m *MBC5) LoadState(r io.Reader) error {
+
+	var s mbc5State
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	m.bankRAM = s.BankRAM
+	m.bankROM = s.BankROM
+	m.enableRAM = s.EnableRAM
+	m.setMotor(s.Motor)
+
+	return m.ram.LoadState(r)
+}
@@ -1,6 +1,10 @@
 package game
 
-import "github.com/moshenahmias/gopherboy/memory"
+import (
+	"io"
+
+	"github.com/moshenahmias/gopherboy/memory"
+)
 
 // NullMBC the ROM is directly mapped to memory at 0000-7FFFh
 type NullMBC struct {
@@ -32,3 +36,18 @@ func (n *NullMBC) Write(addr uint16, data byte) error {
 
 	return memory.WriteOutOfRangeError(addr)
 }
+
+// ROMBank returns the rom bank that is mapped to 4000-7FFF
+func (n *NullMBC) ROMBank() int {
+	return 1
+}
+
+// SaveState does nothing, there is no state to save
+func (n *NullMBC) SaveState(w io.Writer) error {
+	return nil
+}
+
+// LoadState does nothing, there is no state to load
+func (n *NullMBC) LoadState(r io.Reader) error {
+	return nil
+}
@@ -0,0 +1,663 @@
+package gameboy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/audio"
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/display"
+	"github.com/moshenahmias/gopherboy/game"
+	"github.com/moshenahmias/gopherboy/joypad"
+	"github.com/moshenahmias/gopherboy/memory"
+	"github.com/moshenahmias/gopherboy/serial"
+	"github.com/moshenahmias/gopherboy/sgb"
+	"github.com/moshenahmias/gopherboy/timers"
+)
+
+// Create loads the cartridge from 'romFile' and assembles a complete
+// gameboy around it, 'biosData' is optional
+func Create(
+	romFile string,
+	biosData []byte,
+	monitor display.Monitor,
+	audioer audio.Audioer,
+	keystroker joypad.Keystroker,
+	fps uint32) (*Gameboy, error) {
+
+	// create mmu
+	mmu := memory.NewMMU()
+
+	// create cpu core
+	core, err := cpu.NewCore(mmu)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// create and map the joyp register
+	joyp := joypad.NewJOYP(core, keystroker)
+
+	// load cartridge
+	cartridge, err := game.NewCartridge(romFile, core)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// cgb games run in cgb mode
+	cgb := cartridge.Header().CGBSupported()
+
+	if cgb {
+		if err := core.EnableSpeedSwitch(mmu); err != nil {
+			return nil, err
+		}
+	}
+
+	// sgb enhanced monochrome games run with the super game boy functions
+	var s *sgb.SGB
+
+	if !cgb && cartridge.Header().SGBSupported() {
+		s = sgb.NewSGB(joyp)
+		monitor = s.Monitor(monitor)
+	}
+
+	// create gpu
+	gpu, err := display.NewGPU(mmu, monitor, core, cgb)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// create apu
+	apu, err := audio.NewAPU(core, mmu, audioer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// pace the execution to the frame rate (and the queued audio)
+	core.SetFrameRate(fps)
+	core.SetDriftCorrection(apu.Drift)
+
+	// assemble everything
+	gb, err := NewGameboy(cartridge, mmu, core, biosData, joyp, gpu, apu)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gb.sgb = s
+
+	return gb, nil
+}
+
+// stateMagic identifies a save state stream
+const stateMagic = "GBSS"
+
+// stateVersion is the current save state format version
+const stateVersion uint32 = 10
+
+// ErrInvalidState is returned when loading a stream that is not a save state
+var ErrInvalidState = errors.New("ErrInvalidState")
+
+// ioMasks returns the bits that always read 1 in each of the i/o registers
+// (0xFF for a write only register), the other addresses in FF00-FF7F
+// are unmapped and read 0xFF
+func ioMasks(cgb bool) map[uint16]byte {
+
+	masks := map[uint16]byte{
+		joypad.AddrJOYP:    0xC0,
+		serial.AddrSB:      0x00,
+		serial.AddrSC:      0x7E,
+		timers.AddrDIV:     0x00,
+		timers.AddrTIMA:    0x00,
+		timers.AddrTMA:     0x00,
+		timers.AddrTAC:     0xF8,
+		cpu.AddrIF:         0xE0,
+		audio.AddrNR10:     0x80,
+		audio.AddrNR11:     0x3F,
+		audio.AddrNR12:     0x00,
+		audio.AddrNR13:     0xFF,
+		audio.AddrNR14:     0xBF,
+		audio.AddrNR21:     0x3F,
+		audio.AddrNR22:     0x00,
+		audio.AddrNR23:     0xFF,
+		audio.AddrNR24:     0xBF,
+		audio.AddrNR30:     0x7F,
+		audio.AddrNR31:     0xFF,
+		audio.AddrNR32:     0x9F,
+		audio.AddrNR33:     0xFF,
+		audio.AddrNR34:     0xBF,
+		audio.AddrNR41:     0xFF,
+		audio.AddrNR42:     0x00,
+		audio.AddrNR43:     0x00,
+		audio.AddrNR44:     0xBF,
+		audio.AddrNR50:     0x00,
+		audio.AddrNR51:     0x00,
+		audio.AddrNR52:     0x70,
+		display.AddrLCDC:   0x00,
+		display.AddrSTAT:   0x80,
+		display.AddrSCY:    0x00,
+		display.AddrSCX:    0x00,
+		display.AddrLY:     0x00,
+		display.AddrLYC:    0x00,
+		display.AddrDMA:    0x00,
+		display.AddrBGP:    0x00,
+		display.AddrOBP0:   0x00,
+		display.AddrOBP1:   0x00,
+		display.AddrWinY:   0x00,
+		display.AddrWinX:   0x00,
+		memory.AddrBootROM: 0xFF,
+
+		// cgb registers (unmapped in dmg mode)
+		cpu.AddrKEY1:      0x7E,
+		display.AddrVBK:   0xFE,
+		display.AddrHDMA1: 0xFF,
+		display.AddrHDMA2: 0xFF,
+		display.AddrHDMA3: 0xFF,
+		display.AddrHDMA4: 0xFF,
+		display.AddrHDMA5: 0x00,
+		display.AddrBCPS:  0x40,
+		display.AddrBCPD:  0x00,
+		display.AddrOCPS:  0x40,
+		display.AddrOCPD:  0x00,
+		memory.AddrSVBK:   0xF8}
+
+	// wave pattern ram
+	for addr := audio.AddrWaveTableStart; addr <= audio.AddrWaveTableEnd; addr++ {
+		masks[addr] = 0x00
+	}
+
+	// the serial clock speed (SC bit 1)
+	if cgb {
+		masks[serial.AddrSC] = 0x7C
+	}
+
+	return masks
+}
+
+// Gameboy console
+type Gameboy struct {
+	core      *cpu.Core
+	mmu       *memory.MMU
+	cartridge *game.Cartridge
+	joyp      *joypad.JOYP
+	gpu       *display.GPU
+	apu       *audio.APU
+	timer     *timers.Timer
+	serial    *serial.Port
+	wram      *memory.RAM
+	wramBanks *memory.RAM // banks 1 - 7 (cgb)
+	svbk      *memory.WRAMBanker
+	zpram     *memory.RAM
+	biosROM   *memory.ROM
+	biosSize  int
+	rewinder  *Rewinder
+	cgb       bool
+	sgb       *sgb.SGB // nil unless running an sgb enhanced game
+}
+
+// NewGameboy creates Gameboy instance
+func NewGameboy(
+	cartridge *game.Cartridge,
+	mmu *memory.MMU,
+	core *cpu.Core,
+	biosData []byte,
+	joyp *joypad.JOYP,
+	gpu *display.GPU,
+	apu *audio.APU) (*Gameboy, error) {
+
+	// map FEA0-FEFF (unused)
+	if err := mmu.Map(&memory.Null{}, 0xFEA0, 0xFEFF); err != nil {
+		return nil, err
+	}
+
+	// map joypad
+	if err := mmu.Map(joyp, joypad.AddrJOYP, joypad.AddrJOYP); err != nil {
+		return nil, err
+	}
+
+	// create and map the timer
+	timer := timers.NewTimer(core)
+
+	// map timer
+	if err := mmu.Map(timer, 0xFF04, 0xFF07); err != nil {
+		return nil, err
+	}
+
+	// create and map the serial port
+	port := serial.NewPort(core, cartridge.Header().CGBSupported())
+
+	if err := mmu.Map(port, serial.AddrSB, serial.AddrSC); err != nil {
+		return nil, err
+	}
+
+	gb := Gameboy{
+		core:      core,
+		mmu:       mmu,
+		cartridge: cartridge,
+		joyp:      joyp,
+		gpu:       gpu,
+		apu:       apu,
+		timer:     timer,
+		serial:    port,
+		cgb:       cartridge.Header().CGBSupported()}
+
+	// map cartridge
+	if err := mmu.Map(cartridge, 0x0000, 0x7FFF); err != nil {
+		return nil, err
+	}
+
+	if len(biosData) > 0 {
+
+		gb.biosROM = memory.NewROM(biosData, 0)
+		gb.biosSize = len(biosData)
+
+		// map bios
+		if err := gb.mapBIOS(true); err != nil {
+			return nil, err
+		}
+	}
+
+	// map external ram
+	if err := mmu.Map(cartridge, 0xA000, 0xBFFF); err != nil {
+		return nil, err
+	}
+
+	// map bios unmapper
+	if err := mmu.Map(memory.NewBiosUnmapper(mmu, cartridge), memory.AddrBootROM, memory.AddrBootROM); err != nil {
+		return nil, err
+	}
+
+	if gb.cgb {
+
+		// map working ram bank 0
+		gb.wram = memory.NewRAM(make([]byte, memory.WRAMBankSize), 0xC000)
+		if err := mmu.Map(gb.wram, 0xC000, 0xCFFF); err != nil {
+			return nil, err
+		}
+
+		// map working ram banks 1 - 7
+		gb.wramBanks = memory.NewRAM(make([]byte, 7*memory.WRAMBankSize), 0xD000)
+		if err := mmu.Map(gb.wramBanks, 0xD000, 0xDFFF); err != nil {
+			return nil, err
+		}
+
+		// map the bank register
+		gb.svbk = memory.NewWRAMBanker(gb.wramBanks)
+		if err := mmu.Map(gb.svbk, memory.AddrSVBK, memory.AddrSVBK); err != nil {
+			return nil, err
+		}
+
+	} else {
+
+		// map working ram
+		gb.wram = memory.NewRAM(make([]byte, 8192), 0xC000)
+		if err := mmu.Map(gb.wram, 0xC000, 0xDFFF); err != nil {
+			return nil, err
+		}
+	}
+
+	// map shadow
+	for src := uint16(0xE000); src <= uint16(0xFDFF); src++ {
+		if err := mmu.Map(memory.NewEchoer(mmu, src-0x2000), src, src); err != nil {
+			return nil, err
+		}
+	}
+
+	// map zero page information ram
+	gb.zpram = memory.NewRAM(make([]byte, 127), 0xFF80)
+	if err := mmu.Map(gb.zpram, 0xFF80, 0xFFFE); err != nil {
+		return nil, err
+	}
+
+	// map the i/o registers (after all the register units are mapped)
+	if err := mmu.Map(memory.NewIO(mmu, ioMasks(gb.cgb)), memory.IOStart, memory.IOEnd); err != nil {
+		return nil, err
+	}
+
+	core.RegisterToFixedClockChanges(gpu)
+
+	return &gb, nil
+}
+
+// mapBIOS maps the bios (or the cartridge) to 0000-00FF and
+// the rest of a cgb bios (or the cartridge) to 0200-08FF
+func (g *Gameboy) mapBIOS(mapped bool) error {
+
+	var unit memory.Unit = g.cartridge
+
+	if mapped {
+		unit = g.biosROM
+	}
+
+	if err := g.mmu.Map(unit, 0x0000, 0x00FF); err != nil {
+		return err
+	}
+
+	if !mapped || g.biosSize > 0x0200 {
+		return g.mmu.Map(unit, 0x0200, 0x08FF)
+	}
+
+	return nil
+}
+
+// rams returns the ram units, in the save state order
+func (g *Gameboy) rams() []*memory.RAM {
+
+	if g.cgb {
+		return []*memory.RAM{g.wram, g.wramBanks, g.zpram}
+	}
+
+	return []*memory.RAM{g.wram, g.zpram}
+}
+
+// Core returns the cpu core
+func (g *Gameboy) Core() *cpu.Core {
+	return g.core
+}
+
+// MMU returns the memory management unit
+func (g *Gameboy) MMU() *memory.MMU {
+	return g.mmu
+}
+
+// Serial returns the serial link port
+func (g *Gameboy) Serial() *serial.Port {
+	return g.serial
+}
+
+// GPU returns the display unit
+func (g *Gameboy) GPU() *display.GPU {
+	return g.gpu
+}
+
+// APU returns the sound unit
+func (g *Gameboy) APU() *audio.APU {
+	return g.apu
+}
+
+// CGB returns true in cgb mode
+func (g *Gameboy) CGB() bool {
+	return g.cgb
+}
+
+// SGB returns the super game boy (nil unless running an sgb enhanced game)
+func (g *Gameboy) SGB() *sgb.SGB {
+	return g.sgb
+}
+
+// JOYP returns the joypad register
+func (g *Gameboy) JOYP() *joypad.JOYP {
+	return g.joyp
+}
+
+// Cartridge returns the game cartridge
+func (g *Gameboy) Cartridge() *game.Cartridge {
+	return g.cartridge
+}
+
+// SetTracer sets the execution tracer (nil to remove)
+func (g *Gameboy) SetTracer(t *cpu.Tracer) {
+
+	if t != nil {
+		t.SetROMBank(g.cartridge.ROMBank)
+	}
+
+	g.gpu.StubLY(t != nil && t.Doctor())
+	g.core.SetTracer(t)
+}
+
+// Start the cpu
+func (g *Gameboy) Start() error {
+
+	if g.biosROM != nil {
+		return g.core.Start(0x0000)
+	}
+
+	// the registers as left by the cgb bios (a = 11 identifies a cgb)
+	if g.cgb {
+
+		for name, value := range map[string]uint16{"af": 0x1180, "bc": 0x0000, "de": 0xFF56, "hl": 0x000D, "sp": 0xFFFE} {
+			if err := g.core.SetRegister(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return g.core.Start(0x0100)
+}
+
+// Pause the cpu
+func (g *Gameboy) Pause() {
+	g.core.Pause()
+}
+
+// AdvanceFrame runs a single frame and pauses
+func (g *Gameboy) AdvanceFrame() {
+	g.core.AdvanceFrame()
+}
+
+// Stop the cpu
+func (g *Gameboy) Stop() {
+
+	if g.rewinder != nil {
+		g.rewinder.Rewind(false)
+	}
+
+	g.core.Stop()
+}
+
+// SaveState writes a snapshot of the whole machine to 'w'
+func (g *Gameboy) SaveState(w io.Writer) error {
+
+	return g.core.Exec(func() error {
+
+		if _, err := io.WriteString(w, stateMagic); err != nil {
+			return err
+		}
+
+		if err := binary.Write(w, binary.LittleEndian, stateVersion); err != nil {
+			return err
+		}
+
+		return g.saveState(w)
+	})
+}
+
+// LoadState restores a snapshot of the whole machine from 'r'
+func (g *Gameboy) LoadState(r io.Reader) error {
+
+	return g.core.Exec(func() error {
+
+		magic := make([]byte, len(stateMagic))
+
+		if _, err := io.ReadFull(r, magic); err != nil {
+			return err
+		}
+
+		if string(magic) != stateMagic {
+			return ErrInvalidState
+		}
+
+		var version uint32
+
+		if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+			return err
+		}
+
+		if version != stateVersion {
+			return fmt.Errorf("save state version not supported (%d)", version)
+		}
+
+		// keep the current state, in case the stream is corrupted
+		var backup bytes.Buffer
+
+		if err := g.saveState(&backup); err != nil {
+			return err
+		}
+
+		if err := g.loadState(r); err != nil {
+
+			if rerr := g.loadState(&backup); rerr != nil {
+				return fmt.Errorf("%v (rollback failed: %v)", err, rerr)
+			}
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// saveState writes the state of every unit, in a fixed order
+func (g *Gameboy) saveState(w io.Writer) error {
+
+	if err := g.core.SaveState(w); err != nil {
+		return err
+	}
+
+	biosMapped := g.biosROM != nil && g.mmu.Unit(0x0000) == memory.Unit(g.biosROM)
+
+	if err := binary.Write(w, binary.LittleEndian, biosMapped); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, g.cgb); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, g.sgb != nil); err != nil {
+		return err
+	}
+
+	for _, ram := range g.rams() {
+		if err := ram.SaveState(w); err != nil {
+			return err
+		}
+	}
+
+	if g.cgb {
+		if err := g.svbk.SaveState(w); err != nil {
+			return err
+		}
+	}
+
+	if err := g.gpu.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := g.timer.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := g.serial.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := g.joyp.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := g.apu.SaveState(w); err != nil {
+		return err
+	}
+
+	if err := g.cartridge.SaveState(w); err != nil {
+		return err
+	}
+
+	if g.sgb != nil {
+		return g.sgb.SaveState(w)
+	}
+
+	return nil
+}
+
+// loadState reads the state of every unit, in the order written by saveState
+func (g *Gameboy) loadState(r io.Reader) error {
+
+	if err := g.core.LoadState(r); err != nil {
+		return err
+	}
+
+	var biosMapped bool
+
+	if err := binary.Read(r, binary.LittleEndian, &biosMapped); err != nil {
+		return err
+	}
+
+	if biosMapped && g.biosROM == nil {
+		return errors.New("save state requires a boot ROM")
+	}
+
+	if err := g.mapBIOS(biosMapped); err != nil {
+		return err
+	}
+
+	var cgb bool
+
+	if err := binary.Read(r, binary.LittleEndian, &cgb); err != nil {
+		return err
+	}
+
+	if cgb != g.cgb {
+		return errors.New("save state of a different hardware mode (dmg / cgb)")
+	}
+
+	var sgbMode bool
+
+	if err := binary.Read(r, binary.LittleEndian, &sgbMode); err != nil {
+		return err
+	}
+
+	if sgbMode != (g.sgb != nil) {
+		return errors.New("save state of a different hardware mode (dmg / sgb)")
+	}
+
+	for _, ram := range g.rams() {
+		if err := ram.LoadState(r); err != nil {
+			return err
+		}
+	}
+
+	if g.cgb {
+		if err := g.svbk.LoadState(r); err != nil {
+			return err
+		}
+	}
+
+	if err := g.gpu.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := g.timer.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := g.serial.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := g.joyp.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := g.apu.LoadState(r); err != nil {
+		return err
+	}
+
+	if err := g.cartridge.LoadState(r); err != nil {
+		return err
+	}
+
+	if g.sgb != nil {
+		return g.sgb.LoadState(r)
+	}
+
+	return nil
+}
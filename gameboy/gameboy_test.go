@@ -0,0 +1,139 @@
+package gameboy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moshenahmias/gopherboy/audio"
+	"github.com/moshenahmias/gopherboy/display"
+	"github.com/moshenahmias/gopherboy/joypad"
+)
+
+// noKeys is a keystroker that never presses a button
+type noKeys struct{}
+
+// GetKeystroke returns nil
+func (noKeys) GetKeystroke() *joypad.Keystroke {
+	return nil
+}
+
+// testROM returns a 32KB rom (no mbc) that runs 'code' from 0150
+func testROM(code []byte, cgb bool) []byte {
+
+	rom := make([]byte, 0x8000)
+
+	copy(rom[0x100:], []byte{0x00, 0xC3, 0x50, 0x01})
+	copy(rom[0x150:], code)
+
+	if cgb {
+		rom[0x143] = 0x80
+	}
+
+	var checksum byte
+
+	for _, b := range rom[0x134:0x14D] {
+		checksum = checksum - b - 1
+	}
+
+	rom[0x14D] = checksum
+
+	return rom
+}
+
+// testGameboy creates a gameboy around 'rom', that runs as fast as possible
+func testGameboy(t *testing.T, rom []byte) *Gameboy {
+
+	dir, err := ioutil.TempDir("", "gopherboy-test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "test.gb")
+
+	if err := ioutil.WriteFile(file, rom, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gb, err := Create(file, nil, display.NewMemoryMonitor(nil), &audio.NullAudioer{}, noKeys{}, 60)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gb.Core().SetSpeed(0)
+
+	return gb
+}
+
+// TestIOReadMasks writes to the i/o registers and reads them back,
+// the unused bits read 1, write only and unmapped registers read 0xFF
+func TestIOReadMasks(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		cgb   bool
+		addr  uint16
+		write byte
+		want  byte
+	}{
+		{"JOYP buttons", false, 0xFF00, 0x10, 0xDF},
+		{"JOYP none", false, 0xFF00, 0x30, 0xFF},
+		{"SB", false, 0xFF01, 0x5A, 0x5A},
+		{"SC", false, 0xFF02, 0x01, 0x7F},
+		{"SC speed (dmg)", false, 0xFF02, 0x02, 0x7E},
+		{"SC speed (cgb)", true, 0xFF02, 0x02, 0x7E},
+		{"SC (cgb)", true, 0xFF02, 0x00, 0x7C},
+		{"unmapped", false, 0xFF03, 0x00, 0xFF},
+		{"TMA", false, 0xFF06, 0x12, 0x12},
+		{"TAC", false, 0xFF07, 0x00, 0xF8},
+		{"IF", false, 0xFF0F, 0x00, 0xE0},
+		{"NR10", false, 0xFF10, 0x00, 0x80},
+		{"NR11", false, 0xFF11, 0x00, 0x3F},
+		{"NR13", false, 0xFF13, 0x00, 0xFF},
+		{"NR14", false, 0xFF14, 0x00, 0xBF},
+		{"unused sound", false, 0xFF15, 0x00, 0xFF},
+		{"NR30", false, 0xFF1A, 0x00, 0x7F},
+		{"NR32", false, 0xFF1C, 0x00, 0x9F},
+		{"NR41", false, 0xFF20, 0x00, 0xFF},
+		{"NR52", false, 0xFF26, 0x00, 0x70},
+		{"wave ram", false, 0xFF30, 0xA5, 0xA5},
+		{"BGP", false, 0xFF47, 0xE4, 0xE4},
+		{"KEY1 (dmg)", false, 0xFF4D, 0x00, 0xFF},
+		{"KEY1 (cgb)", true, 0xFF4D, 0x00, 0x7E},
+		{"VBK (dmg)", false, 0xFF4F, 0x00, 0xFF},
+		{"VBK (cgb)", true, 0xFF4F, 0x00, 0xFE},
+		{"boot rom", false, 0xFF50, 0x00, 0xFF},
+		{"HDMA1 (cgb)", true, 0xFF51, 0x00, 0xFF},
+		{"BCPS (cgb)", true, 0xFF68, 0x00, 0x40},
+		{"SVBK (dmg)", false, 0xFF70, 0x00, 0xFF},
+		{"SVBK (cgb)", true, 0xFF70, 0x00, 0xF8},
+		{"unmapped (cgb)", true, 0xFF7F, 0x00, 0xFF},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			gb := testGameboy(t, testROM(nil, test.cgb))
+
+			if err := gb.MMU().Write(test.addr, test.write); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := gb.MMU().Read(test.addr)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if data != test.want {
+				t.Errorf("%04x reads %02x, want %02x", test.addr, data, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package gameboy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moshenahmias/gopherboy/serial"
+)
+
+// linkBytes is the number of bytes each side of the link test sends
+const linkBytes = 32
+
+// linkDone is the address of the final loop of the link test rom
+const linkDone uint16 = 0x016C
+
+// linkROM returns a rom that transfers linkBytes bytes (base, base + 1, ...)
+// with the given SC start value (0x81 drives the clock, 0x80 doesn't) and
+// keeps the received bytes at C000
+func linkROM(base, sc byte) []byte {
+
+	return testROM([]byte{
+		0x31, 0xFE, 0xFF, // 0150 ld sp, $fffe
+		0x21, 0x00, 0xC0, // 0153 ld hl, $c000
+		0x06, linkBytes, // 0156 ld b, linkBytes
+		0x7D,       // 0158 ld a, l
+		0xC6, base, // 0159 add a, base
+		0xE0, 0x01, // 015B ldh ($01), a
+		0x3E, sc, // 015D ld a, sc
+		0xE0, 0x02, // 015F ldh ($02), a
+		0xF0, 0x02, // 0161 ldh a, ($02)
+		0x87,       // 0163 add a, a
+		0x38, 0xFB, // 0164 jr c, $0161
+		0xF0, 0x01, // 0166 ldh a, ($01)
+		0x22,       // 0168 ld (hl+), a
+		0x05,       // 0169 dec b
+		0x20, 0xEC, // 016A jr nz, $0158
+		0x18, 0xFE, // 016C jr $016c
+	}, false)
+}
+
+// TestLink connects two gameboys over a localhost link, the clocking side
+// and the clocked side run as fast as possible and exchange linkBytes bytes
+func TestLink(t *testing.T) {
+
+	listener, err := serial.Listen("127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	dialer, err := serial.Dial(listener.Addr().String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dialer.Close()
+
+	// wait for the hello messages
+	for deadline := time.Now().Add(5 * time.Second); !listener.Ready() || !dialer.Ready(); {
+
+		if time.Now().After(deadline) {
+			t.Fatal("the link handshake timed out")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if listener.Master() == dialer.Master() {
+		t.Fatalf("both sides negotiated the same role (master: %t)", listener.Master())
+	}
+
+	tests := []struct {
+		name string
+		base byte
+		sc   byte
+		link *serial.Link
+	}{
+		{"clocking.gb", 0x10, 0x81, listener},
+		{"clocked.gb", 0x80, 0x80, dialer},
+	}
+
+	gbs := make([]*Gameboy, len(tests))
+	done := make(chan error, len(tests))
+
+	for i, test := range tests {
+
+		gb := testGameboy(t, linkROM(test.base, test.sc))
+		gb.Serial().Connect(test.link)
+
+		gb.Core().SetHook(func(pc uint16, opcode byte) bool {
+			return pc != linkDone
+		})
+
+		gbs[i] = gb
+		link := test.link
+
+		// unplug the cable once done, as a quitting gopherboy does
+		go func() {
+			err := gb.Start()
+			link.Close()
+			done <- err
+		}()
+	}
+
+	timeout := time.After(10 * time.Second)
+
+	for range tests {
+
+		select {
+
+		case err := <-done:
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+		case <-timeout:
+
+			for _, gb := range gbs {
+				gb.Stop()
+			}
+
+			t.Fatal("the transfers timed out")
+		}
+	}
+
+	for i, test := range tests {
+
+		other := tests[1-i]
+
+		for n := 0; n < linkBytes; n++ {
+
+			data, err := gbs[i].MMU().Peek(0xC000 + uint16(n))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := other.base + byte(n); data != want {
+				t.Fatalf("%s: byte %d is %02x, want %02x", test.name, n, data, want)
+			}
+		}
+	}
+}
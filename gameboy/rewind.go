@@ -0,0 +1,243 @@
+package gameboy
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+)
+
+// rewindFrameTime is how long each restored snapshot is shown while rewinding
+const rewindFrameTime = time.Second / 60
+
+// snapshot is a compressed save state, either a full state or the
+// xor delta that turns the next (newer) snapshot into this one
+type snapshot struct {
+	data []byte
+	full bool
+}
+
+// Rewinder keeps a ring buffer of compressed snapshots, one every
+// 'interval' frames, the oldest snapshots are dropped when the buffer
+// grows over the memory budget
+type Rewinder struct {
+	gb        *Gameboy
+	interval  int
+	budget    int
+	frames    int
+	current   []byte     // the newest snapshot (uncompressed)
+	snapshots []snapshot // older snapshots, oldest first
+	size      int        // total size of the compressed snapshots
+	rewinding int32
+	restored  bool // current was restored during this rewind
+	state     bytes.Buffer
+	compress  bytes.Buffer
+	w         *flate.Writer
+}
+
+// NewRewinder creates Rewinder instance that takes a snapshot of 'gb'
+// every 'interval' frames, up to 'budget' bytes of compressed snapshots
+func NewRewinder(gb *Gameboy, interval int, budget int) *Rewinder {
+
+	if interval < 1 {
+		interval = 1
+	}
+
+	w, _ := flate.NewWriter(nil, flate.BestSpeed)
+
+	r := &Rewinder{gb: gb, interval: interval, budget: budget, w: w}
+
+	gb.rewinder = r
+	gb.core.SetFrameHook(r.frame)
+	gb.core.SetPauseHook(r.paused)
+
+	return r
+}
+
+// Rewind starts (or stops) rewinding, safe to call from any goroutine
+func (r *Rewinder) Rewind(on bool) {
+
+	if on {
+		atomic.StoreInt32(&r.rewinding, 1)
+	} else {
+		atomic.StoreInt32(&r.rewinding, 0)
+	}
+}
+
+// Rewinding returns true while rewinding
+func (r *Rewinder) Rewinding() bool {
+	return atomic.LoadInt32(&r.rewinding) != 0
+}
+
+// Len returns the number of snapshots in the buffer
+func (r *Rewinder) Len() int {
+
+	if r.current == nil {
+		return 0
+	}
+
+	return len(r.snapshots) + 1
+}
+
+// Size returns the total size of the compressed snapshots in bytes
+func (r *Rewinder) Size() int {
+	return r.size
+}
+
+// frame is called at the end of every frame (by the core)
+func (r *Rewinder) frame() {
+
+	if r.Rewinding() {
+		r.rewind()
+		return
+	}
+
+	r.frames++
+
+	if r.frames < r.interval {
+		return
+	}
+
+	r.frames = 0
+
+	if err := r.push(); err != nil {
+		// drop the whole buffer rather than keeping a broken chain
+		r.current = nil
+		r.snapshots = nil
+		r.size = 0
+	}
+}
+
+// paused is called repeatedly while the core is paused, the
+// snapshots are restored as long as the rewinding goes on
+func (r *Rewinder) paused() {
+
+	if r.Rewinding() {
+		r.rewind()
+	}
+}
+
+// push a snapshot of the current state
+func (r *Rewinder) push() error {
+
+	r.state.Reset()
+
+	if err := r.gb.SaveState(&r.state); err != nil {
+		return err
+	}
+
+	state := append([]byte(nil), r.state.Bytes()...)
+
+	if r.current != nil {
+
+		// the delta that restores the previous snapshot from the new one
+		prev := snapshot{data: r.current, full: len(r.current) != len(state)}
+
+		if !prev.full {
+
+			prev.data = make([]byte, len(state))
+
+			for i := range state {
+				prev.data[i] = state[i] ^ r.current[i]
+			}
+		}
+
+		data, err := r.deflate(prev.data)
+
+		if err != nil {
+			return err
+		}
+
+		prev.data = data
+
+		r.snapshots = append(r.snapshots, prev)
+		r.size += len(data)
+	}
+
+	r.current = state
+
+	// drop the oldest snapshots
+	for r.size > r.budget && len(r.snapshots) > 0 {
+		r.size -= len(r.snapshots[0].data)
+		r.snapshots[0] = snapshot{}
+		r.snapshots = r.snapshots[1:]
+	}
+
+	return nil
+}
+
+// rewind restores the snapshots in reverse order until the rewinding stops
+func (r *Rewinder) rewind() {
+
+	for r.Rewinding() {
+
+		if err := r.pop(); err != nil {
+			break
+		}
+
+		time.Sleep(rewindFrameTime)
+	}
+
+	r.restored = false
+	r.frames = 0
+}
+
+// pop restores the previous snapshot (the newest one first)
+// and sends its frame to the monitor
+func (r *Rewinder) pop() error {
+
+	if r.current == nil {
+		return nil
+	}
+
+	if r.restored {
+
+		if len(r.snapshots) == 0 {
+			return nil
+		}
+
+		prev := r.snapshots[len(r.snapshots)-1]
+		r.snapshots = r.snapshots[:len(r.snapshots)-1]
+		r.size -= len(prev.data)
+
+		data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(prev.data)))
+
+		if err != nil {
+			return err
+		}
+
+		if !prev.full {
+			for i := range data {
+				data[i] ^= r.current[i]
+			}
+		}
+
+		r.current = data
+	}
+
+	r.restored = true
+
+	if err := r.gb.LoadState(bytes.NewReader(r.current)); err != nil {
+		return err
+	}
+
+	return r.gb.core.Exec(r.gb.gpu.Redraw)
+}
+
+// deflate compresses 'data'
+func (r *Rewinder) deflate(data []byte) ([]byte, error) {
+
+	r.compress.Reset()
+	r.w.Reset(&r.compress)
+
+	if _, err := r.w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := r.w.Close(); err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), r.compress.Bytes()...), nil
+}
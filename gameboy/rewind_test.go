@@ -0,0 +1,70 @@
+package gameboy
+
+import "testing"
+
+// TestRewind pushes a snapshot after each write to the work ram and pops
+// them back through the xor deltas, the oldest snapshots are dropped when
+// they don't fit in the budget
+func TestRewind(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		budget int
+		values []byte
+		want   []byte // the restored values, newest first
+	}{
+		{"single", 1 << 20, []byte{0x11}, []byte{0x11, 0x11}},
+		{"round trip", 1 << 20, []byte{0x11, 0x22, 0x33, 0x44}, []byte{0x44, 0x33, 0x22, 0x11, 0x11}},
+		{"no budget", 0, []byte{0x11, 0x22, 0x33}, []byte{0x33, 0x33}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			gb := testGameboy(t, testROM(nil, false))
+			r := NewRewinder(gb, 1, test.budget)
+
+			for _, value := range test.values {
+
+				if err := gb.MMU().Write(0xC000, value); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := r.push(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if r.Len() != len(test.want)-1 {
+				t.Errorf("%d snapshots, want %d", r.Len(), len(test.want)-1)
+			}
+
+			// scribble over the state, the snapshots bring it back
+			if err := gb.MMU().Write(0xC000, 0xFF); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, want := range test.want {
+
+				if err := r.pop(); err != nil {
+					t.Fatal(err)
+				}
+
+				data, err := gb.MMU().Read(0xC000)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if data != want {
+					t.Errorf("pop %d restored %02x, want %02x", i, data, want)
+				}
+			}
+
+			if r.Size() != 0 {
+				t.Errorf("%d bytes left after popping every snapshot", r.Size())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/moshenahmias/gopherboy/game"
+)
+
+// printROMInfo prints the decoded header of the given rom file
+func printROMInfo(romFile string) error {
+
+	romData, err := ioutil.ReadFile(romFile)
+
+	if err != nil {
+		return err
+	}
+
+	h, err := game.ParseHeader(romData)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Title:             %s\n", h.Title)
+
+	if len(h.ManufacturerCode) > 0 {
+		fmt.Printf("Manufacturer code: %s\n", h.ManufacturerCode)
+	}
+
+	fmt.Printf("CGB flag:          %02x (supported: %t, only: %t)\n", h.CGBFlag, h.CGBSupported(), h.CGBOnly())
+	fmt.Printf("SGB flag:          %02x (supported: %t)\n", h.SGBFlag, h.SGBSupported())
+	fmt.Printf("Licensee:          %s\n", h.Licensee())
+	fmt.Printf("Cartridge type:    %02x (%s)\n", h.CartridgeType, h.CartridgeTypeName())
+	fmt.Printf("ROM size:          %02x (%d bytes)\n", h.ROMSize, h.ROMBytes())
+	fmt.Printf("RAM size:          %02x (%d bytes)\n", h.RAMSize, h.RAMBytes())
+	fmt.Printf("Destination:       %02x (%s)\n", h.DestinationCode, h.Destination())
+	fmt.Printf("Version:           %02x\n", h.Version)
+	fmt.Printf("Header checksum:   %02x (valid: %t)\n", h.HeaderChecksum, h.HeaderChecksumValid())
+	fmt.Printf("Global checksum:   %04x (valid: %t)\n", h.GlobalChecksum, h.GlobalChecksumValid())
+
+	if h.ROMBytes() != len(romData) {
+		fmt.Printf("Warning: ROM size byte doesn't match the file length (%d bytes)\n", len(romData))
+	}
+
+	return nil
+}
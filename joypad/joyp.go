@@ -1,6 +1,12 @@
 package joypad
 
-import "github.com/moshenahmias/gopherboy/cpu"
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+)
 
 const columnDirections byte = 0x20
 const columnStartSelectAB byte = 0x10
@@ -40,29 +46,82 @@ type Keystroker interface {
 	GetKeystroke() *Keystroke
 }
 
+// LinesListener is notified about every write to the P14 / P15 lines
+// (e.g. the super game boy reads its command packets from the lines)
+type LinesListener interface {
+	LinesWritten(lines byte)
+}
+
 // AddrJOYP is the address for the joypad register
 const AddrJOYP uint16 = 0xFF00
 
+// MaxPlayers is the number of joypads (sgb multiplayer)
+const MaxPlayers int = 4
+
 // JOYP register
 type JOYP struct {
-	data       byte
-	core       *cpu.Core
-	keystroker Keystroker
-	state      [2]byte
+	data        byte
+	core        *cpu.Core
+	keystrokers [MaxPlayers]Keystroker
+	state       [MaxPlayers][2]byte
+	players     int // the number of active joypads
+	player      int // the selected joypad
+	listener    LinesListener
+}
+
+// joypState is the serialized form of the joyp register
+type joypState struct {
+	Data    byte
+	State   [MaxPlayers][2]byte
+	Players byte
+	Player  byte
 }
 
-// NewJOYP creates JOYP instance
+// NewJOYP creates JOYP instance, 'keystroker' is the first player's joypad
 func NewJOYP(core *cpu.Core, keystroker Keystroker) *JOYP {
 
-	j := JOYP{core: core, keystroker: keystroker}
+	j := JOYP{core: core, players: 1}
+
+	j.keystrokers[0] = keystroker
+
+	for i := range j.state {
+		j.state[i][0] = 0x0F
+		j.state[i][1] = 0x0F
+	}
 
-	j.state[0] = 0x0F
-	j.state[1] = 0x0F
 	j.data = 0xFF
 
 	return &j
 }
 
+// SetKeystroker sets the joypad of 'player' (0 - 3), the other players
+// are read after the super game boy enables the multiplayer mode
+func (j *JOYP) SetKeystroker(player int, keystroker Keystroker) {
+	j.keystrokers[player] = keystroker
+}
+
+// SetPlayers sets the number of active joypads (1, 2 or 4),
+// the first joypad is selected
+func (j *JOYP) SetPlayers(players int) {
+
+	if players < 1 || players > MaxPlayers {
+		players = 1
+	}
+
+	j.players = players
+	j.player = 0
+}
+
+// Players returns the number of active joypads
+func (j *JOYP) Players() int {
+	return j.players
+}
+
+// SetLinesListener sets the listener of the P14 / P15 lines (nil to remove)
+func (j *JOYP) SetLinesListener(l LinesListener) {
+	j.listener = l
+}
+
 // Read joyp
 func (j *JOYP) Read(addr uint16) (byte, error) {
 
@@ -74,35 +133,60 @@ func (j *JOYP) Read(addr uint16) (byte, error) {
 // Write to joyp
 func (j *JOYP) Write(addr uint16, data byte) error {
 
+	prev := j.data
+
 	j.data = (j.data & 0x0F) | (data & 0xF0)
 
+	// the next joypad is selected when P15 goes high (multiplayer)
+	if j.players > 1 && prev&0x20 == 0x00 && data&0x20 == 0x20 {
+		j.player = (j.player + 1) % j.players
+	}
+
+	if j.listener != nil {
+		j.listener.LinesWritten(data & 0x30)
+	}
+
 	return nil
 }
 
 // setWireState according to the buttons state
 func (j *JOYP) setWireState() error {
 
-	if ks := j.keystroker.GetKeystroke(); ks != nil {
-		btn := ks.Button
+	for i, keystroker := range j.keystrokers {
+
+		if keystroker == nil {
+			continue
+		}
+
+		if ks := keystroker.GetKeystroke(); ks != nil {
+			btn := ks.Button
 
-		if ks.Pressed {
-			j.state[btn>>4] &= ^(btn & 0x0F)
-		} else {
-			j.state[btn>>4] |= (btn & 0x0F)
+			if ks.Pressed {
+				j.state[i][btn>>4] &= ^(btn & 0x0F)
+			} else {
+				j.state[i][btn>>4] |= (btn & 0x0F)
+			}
 		}
 	}
 
+	state := j.state[j.player]
+
 	prev := j.data & 0x0F
 
 	if j.data&0x30 == columnStartSelectAB {
 
 		// buttons A, B, Select or Start
-		j.data = (j.data & 0xF0) | j.state[1]
+		j.data = (j.data & 0xF0) | state[1]
 
 	} else if j.data&0x30 == columnDirections {
 
 		// direction buttons
-		j.data = (j.data & 0xF0) | j.state[0]
+		j.data = (j.data & 0xF0) | state[0]
+
+	} else if j.players > 1 {
+
+		// the selected joypad id (multiplayer)
+		j.data = (j.data & 0xF0) | (0x0F - byte(j.player))
 
 	} else {
 
@@ -118,3 +202,37 @@ func (j *JOYP) setWireState() error {
 
 	return nil
 }
+
+// SaveState writes the selected lines, the buttons state of
+// every joypad and the multiplayer selection to 'w'
+func (j *JOYP) SaveState(w io.Writer) error {
+
+	s := joypState{
+		Data:    j.data,
+		State:   j.state,
+		Players: byte(j.players),
+		Player:  byte(j.player)}
+
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// LoadState reads the lines, the buttons state and the multiplayer selection from 'r'
+func (j *JOYP) LoadState(r io.Reader) error {
+
+	var s joypState
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	if s.Players < 1 || int(s.Players) > MaxPlayers || s.Player >= s.Players {
+		return fmt.Errorf("invalid joypads selection (%d / %d)", s.Player, s.Players)
+	}
+
+	j.data = s.Data
+	j.state = s.State
+	j.players = int(s.Players)
+	j.player = int(s.Player)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package joypad
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buttonNames maps the script button names to button codes
+var buttonNames = map[string]byte{
+	"right":  ButtonRight,
+	"a":      ButtonA,
+	"left":   ButtonLeft,
+	"b":      ButtonB,
+	"up":     ButtonUp,
+	"select": ButtonSelect,
+	"down":   ButtonDown,
+	"start":  ButtonStart}
+
+// ScriptedKeystroke is a keystroke that takes place at a given frame
+type ScriptedKeystroke struct {
+	Frame int
+	Keystroke
+}
+
+// ScriptedKeystroker is a Keystroker that plays a list of keystrokes
+type ScriptedKeystroker struct {
+	script []ScriptedKeystroke
+	clock  func() int
+}
+
+// NewScriptedKeystroker creates ScriptedKeystroker instance, 'clock'
+// returns the current frame number
+func NewScriptedKeystroker(script []ScriptedKeystroke, clock func() int) *ScriptedKeystroker {
+
+	s := make([]ScriptedKeystroke, len(script))
+	copy(s, script)
+
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].Frame < s[j].Frame
+	})
+
+	return &ScriptedKeystroker{script: s, clock: clock}
+}
+
+// GetKeystroke returns the next keystroke that is due (or nil)
+func (s *ScriptedKeystroker) GetKeystroke() *Keystroke {
+
+	if len(s.script) == 0 || s.script[0].Frame > s.clock() {
+		return nil
+	}
+
+	ks := s.script[0].Keystroke
+
+	s.script = s.script[1:]
+
+	return &ks
+}
+
+// ParseScript parses a comma separated list of keystrokes, each in
+// the form of <frame>:<+|-><button>, e.g. "120:+start,130:-start"
+// ('+' presses and '-' releases the button)
+func ParseScript(script string) ([]ScriptedKeystroke, error) {
+
+	var keystrokes []ScriptedKeystroke
+
+	for _, entry := range strings.Split(script, ",") {
+
+		entry = strings.TrimSpace(entry)
+
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+
+		if len(parts) != 2 || len(parts[1]) < 2 {
+			return nil, fmt.Errorf("invalid keystroke (%s)", entry)
+		}
+
+		frame, err := strconv.Atoi(parts[0])
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid keystroke frame (%s)", entry)
+		}
+
+		var pressed bool
+
+		switch parts[1][0] {
+		case '+':
+			pressed = true
+		case '-':
+			pressed = false
+		default:
+			return nil, fmt.Errorf("invalid keystroke action (%s)", entry)
+		}
+
+		btn, found := buttonNames[strings.ToLower(parts[1][1:])]
+
+		if !found {
+			return nil, fmt.Errorf("invalid keystroke button (%s)", entry)
+		}
+
+		keystrokes = append(keystrokes, ScriptedKeystroke{
+			Frame:     frame,
+			Keystroke: Keystroke{Button: btn, Pressed: pressed}})
+	}
+
+	return keystrokes, nil
+}
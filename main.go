@@ -1,23 +1,44 @@
 package main
 
 import (
+	"bufio"
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/moshenahmias/gopherboy/audio"
 	"github.com/moshenahmias/gopherboy/config"
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/display"
-	"github.com/moshenahmias/gopherboy/game"
-	"github.com/moshenahmias/gopherboy/joypad"
-	"github.com/moshenahmias/gopherboy/memory"
+	"github.com/moshenahmias/gopherboy/gameboy"
+	"github.com/moshenahmias/gopherboy/record"
+	"github.com/moshenahmias/gopherboy/serial"
 	"github.com/moshenahmias/gopherboy/ui"
 
 	"github.com/sirupsen/logrus"
 )
 
+// options of the emulator run
+type options struct {
+	romFile         string
+	biosFile        string
+	speed           float64
+	ffSpeed         float64
+	rewindInterval  int
+	rewindBuffer    int
+	debug           bool
+	screenshotDir   string
+	screenshotScale int
+	record          string
+	wavFile         string
+	stemsPrefix     string
+}
+
 func main() {
 
 	// set logging level
@@ -27,6 +48,25 @@ func main() {
 	argROM := flag.String("rom", "", "Path to game ROM")
 	argBIOS := flag.String("bios", "", "Path to boot ROM")
 	argSettings := flag.String("settings", config.DefaultSettingsFile, "Path to settings file")
+	argInfo := flag.Bool("info", false, "Print the ROM header and exit")
+	argSpeed := flag.Float64("speed", 1, "Speed multiplier (0 = as fast as possible)")
+	argFFSpeed := flag.Float64("ff-speed", 0, "Fast forward speed multiplier, while Tab is held (0 = as fast as possible)")
+	argRewindInterval := flag.Int("rewind-interval", 4, "Number of frames between rewind snapshots")
+	argRewindBuffer := flag.Int("rewind-buffer", 32, "Size of the rewind buffer in MB, while Backspace is held (0 = disabled)")
+	argScreenshotDir := flag.String("screenshot-dir", ".", "Directory of the screenshots (F12)")
+	argScreenshotScale := flag.Int("screenshot-scale", 1, "Scale of the screenshots")
+	argRecord := flag.String("record", "", "Record to file from the start (.avi, .y4m + .wav or .gif), F10 toggles the recording")
+	argWAV := flag.String("wav", "", "Write the sound to a WAV file")
+	argStems := flag.String("stems", "", "Write each sound channel to its own WAV file (<prefix>-square1.wav, -square2, -wave and -noise)")
+	argDebug := flag.Bool("debug", false, "Start with the debugger (commands are read from the terminal)")
+	argTrace := flag.String("trace", "", "Path to execution trace file (F9 toggles the tracing)")
+	argTracePC := flag.String("trace-pc", "", "Trace only the given pc range (e.g. 0150-3fff)")
+	argTraceBank := flag.Int("trace-bank", -1, "Trace only the given rom bank")
+	argTraceDoctor := flag.Bool("trace-doctor", false, "Stub LY to $90 while tracing (for gameboy-doctor)")
+	argSerial := flag.String("serial", "none", "Serial link peer (none, loopback, stdout or printer)")
+	argPrintDir := flag.String("print-dir", ".", "Directory of the printer's PNG files (with -serial printer)")
+	argLinkListen := flag.String("link-listen", "", "Listen for a link cable connection on address (e.g. :5000)")
+	argLinkConnect := flag.String("link-connect", "", "Connect the link cable to address (e.g. 192.168.1.2:5000)")
 
 	// parse command-line arguments
 	flag.Parse()
@@ -37,6 +77,16 @@ func main() {
 		return
 	}
 
+	// print rom info
+	if *argInfo {
+
+		if err := printROMInfo(*argROM); err != nil {
+			logrus.Error(err)
+		}
+
+		return
+	}
+
 	// load settings
 	settings, err := config.LoadSettings(*argSettings)
 
@@ -45,13 +95,118 @@ func main() {
 		return
 	}
 
+	// create the serial link peer
+	var peer serial.Peer
+
+	switch {
+
+	case len(*argLinkListen) > 0:
+
+		link, err := serial.Listen(*argLinkListen)
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		defer link.Close()
+
+		logrus.Infof("waiting for a link cable connection on %s", link.Addr())
+
+		peer = link
+
+	case len(*argLinkConnect) > 0:
+
+		link, err := serial.Dial(*argLinkConnect)
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		defer link.Close()
+
+		peer = link
+
+	case *argSerial == "printer":
+
+		printer := serial.NewPrinter(*argPrintDir)
+
+		printer.OnPrint(func(file string, err error) {
+			if err != nil {
+				logrus.Error(err)
+			} else {
+				logrus.Infof("printed %s", file)
+			}
+		})
+
+		defer printer.Flush()
+
+		peer = printer
+
+	default:
+
+		if peer, err = serial.NewPeer(*argSerial); err != nil {
+			logrus.Error(err)
+			return
+		}
+	}
+
+	// create the execution tracer
+	var tracer *cpu.Tracer
+
+	if len(*argTrace) > 0 {
+
+		f, err := os.Create(*argTrace)
+
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		defer f.Close()
+
+		tracer = cpu.NewTracer(f)
+
+		defer tracer.Flush()
+
+		if len(*argTracePC) > 0 {
+
+			from, to, err := cpu.ParseHexRange(*argTracePC)
+
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+
+			tracer.SetRange(from, to)
+		}
+
+		tracer.SetBank(*argTraceBank)
+		tracer.SetDoctor(*argTraceDoctor)
+	}
+
 	// run
-	if err := run(*argROM, *argBIOS, settings); err != nil {
+	opts := options{
+		romFile:         *argROM,
+		biosFile:        *argBIOS,
+		speed:           *argSpeed,
+		ffSpeed:         *argFFSpeed,
+		rewindInterval:  *argRewindInterval,
+		rewindBuffer:    *argRewindBuffer,
+		debug:           *argDebug,
+		screenshotDir:   *argScreenshotDir,
+		screenshotScale: *argScreenshotScale,
+		record:          *argRecord,
+		wavFile:         *argWAV,
+		stemsPrefix:     *argStems}
+
+	if err := run(opts, settings, tracer, peer); err != nil {
 		logrus.Error(err)
 	}
 }
 
-func run(romFile, biosFile string, settings *config.Settings) error {
+func run(opts options, settings *config.Settings, tracer *cpu.Tracer, peer serial.Peer) error {
 
 	runtime.LockOSThread()
 
@@ -63,7 +218,11 @@ func run(romFile, biosFile string, settings *config.Settings) error {
 	defer ui.Close()
 
 	// create input listener
-	input := ui.NewInput(settings.JoypadMapping)
+	input := ui.NewInput(
+		settings.JoypadMapping,
+		settings.JoypadMapping_2,
+		settings.JoypadMapping_3,
+		settings.JoypadMapping_4)
 
 	// create and show the window
 	window, err := ui.NewWindow(
@@ -91,73 +250,166 @@ func run(romFile, biosFile string, settings *config.Settings) error {
 		defer sound.Close()
 	}
 
+	var audioer audio.Audioer = &sound
+
+	// write the sound to a wav file on its way to the sound device
+	if len(opts.wavFile) > 0 {
+
+		wav, err := audio.NewWAVAudioer(opts.wavFile, audioer)
+
+		if err != nil {
+			return err
+		}
+
+		defer wav.Close()
+
+		audioer = wav
+	}
+
+	// write the output of each channel
+	var stems *audio.Stems
+
+	if len(opts.stemsPrefix) > 0 {
+
+		stems, err = audio.NewStems(opts.stemsPrefix, audioer.Frequency())
+
+		if err != nil {
+			return err
+		}
+
+		defer stems.Close()
+	}
+
+	// record the frames and samples on their way to the window and sound device
+	colors := [4]uint32{settings.Color_0, settings.Color_1, settings.Color_2, settings.Color_3}
+	recorder := record.NewRecorder(colors)
+	monitor := recorder.Monitor(window)
+	audioer = recorder.Audioer(audioer)
+
+	recorder.SetSplitHandler(func(file string, why error) {
+		logrus.Infof("%s, recording continues in %s", why, file)
+	})
+
+	recorder.SetErrorHandler(func(file string, err error) {
+		logrus.Errorf("recording to %s stopped: %s", file, err)
+	})
+
+	if len(opts.record) > 0 {
+
+		if err := recorder.Start(opts.record); err != nil {
+			return err
+		}
+
+		logrus.Infof("recording to %s", opts.record)
+	}
+
+	defer func() {
+		if recorder.Recording() {
+			if err := recorder.Stop(); err != nil {
+				logrus.Error(err)
+			} else {
+				logrus.Infof("recording saved to %s", recorder.File())
+			}
+		}
+	}()
+
 	// load bios (if available)
 	var biosData []byte
 
-	if len(biosFile) > 0 {
+	if len(opts.biosFile) > 0 {
 
 		var err error
 
 		// load bios from file
-		biosData, err = ioutil.ReadFile(biosFile)
+		biosData, err = ioutil.ReadFile(opts.biosFile)
 
 		if err != nil {
 			return err
 		}
 	}
 
+	// debugger front end
+	var dbg *repl
+
+	if opts.debug {
+		dbg = newREPL(os.Stdin, os.Stdout, input.Stop)
+	}
+
 	soundMute := false
 
+	// slow motion multiplier (1 = off)
+	slowMotion := 1.0
+	fastForward := false
+
 	// restart loop
 	for quit := false; !quit; {
 
 		// set sound mute state
 		sound.Mute(soundMute)
 
-		// create mmu
-		mmu := memory.NewMMU()
+		// create the gameboy
+		gb, err := gameboy.Create(opts.romFile, biosData, monitor, audioer, input, settings.Fps)
 
-		// TODO: map missing IO registers to FF00-FF7F
-		if err := mmu.Map(memory.NewRAM(make([]byte, 128), 0xFF00), 0xFF00, 0xFF7F); err != nil {
+		if err != nil {
 			return err
 		}
 
-		// create cpu core
-		core, err := cpu.NewCore(mmu)
+		// the keys of the previous game don't reach the new one
+		input.Reset()
 
-		if err != nil {
-			return err
+		if fastForward {
+			gb.Core().SetSpeed(opts.ffSpeed)
+		} else {
+			gb.Core().SetSpeed(opts.speed * slowMotion)
 		}
 
-		// create and map the joyp register
-		joyp := joypad.NewJOYP(core, input)
+		cartridge := gb.Cartridge()
 
-		// create gpu
-		gpu, err := display.NewGPU(mmu, window, core, settings.Fps)
+		if gb.CGB() {
+			logrus.Debug("running in cgb mode")
+		} else if gb.SGB() != nil {
 
-		if err != nil {
+			logrus.Debug("running in sgb mode")
+
+			// the additional players of the sgb multiplayer mode
+			for n := 1; n < input.Players(); n++ {
+				gb.JOYP().SetKeystroker(n, input.Player(n))
+			}
+
+		} else if err := colorize(gb, settings.DmgPalette); err != nil {
 			return err
 		}
 
-		// create apu
-		_, err = audio.NewAPU(core, mmu, &sound)
+		if h := cartridge.Header(); h.ROMBytes() != cartridge.ROMSize() {
+			logrus.Warnf("ROM size byte (%02x) doesn't match the file length (%d bytes)", h.ROMSize, cartridge.ROMSize())
+		}
 
-		if err != nil {
-			return err
+		cartridge.OnRumble(func(on bool) {
+			logrus.Debugf("rumble motor on: %t", on)
+		})
+
+		cartridge.OnSaveError(func(err error) {
+			logrus.Error(err)
+		})
+
+		if dbg != nil {
+			dbg.attach(gb)
+		}
+
+		if tracer != nil {
+			gb.SetTracer(tracer)
 		}
 
-		// load cartridg
-		cartridge, err := game.NewCartridge(romFile, core)
+		gb.Serial().Connect(peer)
 
-		if err != nil {
-			return err
+		if stems != nil {
+			gb.APU().SetStems(stems)
 		}
 
-		// assemble everything
-		gameboy, err := NewGameboy(cartridge, mmu, core, biosData, joyp, gpu)
+		var rewinder *gameboy.Rewinder
 
-		if err != nil {
-			return err
+		if opts.rewindBuffer > 0 {
+			rewinder = gameboy.NewRewinder(gb, opts.rewindInterval, opts.rewindBuffer<<20)
 		}
 
 		// start the game
@@ -166,7 +418,7 @@ func run(romFile, biosFile string, settings *config.Settings) error {
 
 		go func() {
 
-			if err := gameboy.Start(); err != nil {
+			if err := gb.Start(); err != nil {
 				logrus.Error(err)
 				input.Stop()
 			}
@@ -177,17 +429,112 @@ func run(romFile, biosFile string, settings *config.Settings) error {
 		// wait for keyboard events
 		keyEvent := input.WaitForKeyEvents()
 
-		for keyEvent == ui.ControlEventPause || keyEvent == ui.ControlEventMute {
+		for keyEvent != ui.ControlEventQuit && keyEvent != ui.ControlEventReset {
 
-			// pause
-			if keyEvent == ui.ControlEventPause {
-				gameboy.Pause()
-			}
+			switch keyEvent {
+
+			case ui.ControlEventPause:
+
+				gb.Pause()
+
+			case ui.ControlEventMute:
 
-			// mute
-			if keyEvent == ui.ControlEventMute {
 				soundMute = !soundMute
 				sound.Mute(soundMute)
+
+			case ui.ControlEventFastForward:
+
+				fastForward = true
+				gb.Core().SetSpeed(opts.ffSpeed)
+
+			case ui.ControlEventFastForwardEnd:
+
+				fastForward = false
+				gb.Core().SetSpeed(opts.speed * slowMotion)
+
+			case ui.ControlEventSlowMotion:
+
+				// 1 -> 0.5 -> 0.25 -> 1
+				if slowMotion /= 2; slowMotion < 0.25 {
+					slowMotion = 1
+				}
+
+				if !fastForward {
+					gb.Core().SetSpeed(opts.speed * slowMotion)
+				}
+
+				logrus.Infof("speed: %gx", opts.speed*slowMotion)
+
+			case ui.ControlEventFrameAdvance:
+
+				gb.AdvanceFrame()
+
+			case ui.ControlEventRewind, ui.ControlEventRewindEnd:
+
+				if rewinder != nil {
+					rewinder.Rewind(keyEvent == ui.ControlEventRewind)
+				}
+
+			case ui.ControlEventScreenshot:
+
+				if file, err := gb.GPU().Screenshot(opts.screenshotDir, cartridge.Header().Title, colors, opts.screenshotScale); err != nil {
+					logrus.Error(err)
+				} else {
+					logrus.Infof("screenshot saved to %s", file)
+				}
+
+			case ui.ControlEventRecord:
+
+				if recorder.Recording() {
+
+					if err := recorder.Stop(); err != nil {
+						logrus.Error(err)
+					} else {
+						logrus.Infof("recording saved to %s", recorder.File())
+					}
+
+					break
+				}
+
+				file := record.FileName(".", cartridge.Header().Title, ".avi")
+
+				if len(opts.record) > 0 {
+					file = record.FileName(filepath.Dir(opts.record), cartridge.Header().Title, filepath.Ext(opts.record))
+				}
+
+				if err := recorder.Start(file); err != nil {
+					logrus.Error(err)
+				} else {
+					logrus.Infof("recording to %s", file)
+				}
+
+			case ui.ControlEventTrace:
+
+				if tracer == nil {
+					logrus.Warn("tracing is off (see -trace)")
+				} else {
+					logrus.Infof("tracing enabled: %t", tracer.Toggle())
+				}
+
+			case ui.ControlEventSelectSlot:
+
+				logrus.Infof("state slot %d selected", input.Slot())
+
+			case ui.ControlEventSaveState:
+
+				if err := saveState(gb, stateFile(opts.romFile, input.Slot())); err != nil {
+					logrus.Error(err)
+				} else {
+					logrus.Infof("state saved to slot %d", input.Slot())
+				}
+
+			case ui.ControlEventLoadState:
+
+				if err := loadState(gb, stateFile(opts.romFile, input.Slot())); err != nil {
+					logrus.Error(err)
+				} else {
+					logrus.Infof("state loaded from slot %d", input.Slot())
+				}
 			}
 
 			keyEvent = input.WaitForKeyEvents()
@@ -197,11 +544,102 @@ func run(romFile, biosFile string, settings *config.Settings) error {
 		quit = keyEvent == ui.ControlEventQuit
 
 		// stop cpu
-		gameboy.Stop()
+		gb.Stop()
 
 		wg.Wait()
+
+		// save the battery backed ram
+		if err := cartridge.Flush(); err != nil {
+			logrus.Error(err)
+		}
 	}
 
 	// bye
 	return nil
 }
+
+// colorize a monochrome game with the 'palette' setting, "auto" picks the
+// palettes like the cgb boot rom, a button combination (e.g. "left+a")
+// picks one of the manual palettes and "" keeps the shades of the settings
+func colorize(gb *gameboy.Gameboy, palette string) error {
+
+	switch strings.ToLower(palette) {
+	case "", "none":
+		return nil
+	case "auto":
+		gb.GPU().SetDMGPalettes(display.AutoDMGPalettes(gb.Cartridge().Header()))
+		return nil
+	}
+
+	p, found := display.ManualDMGPalettes(palette)
+
+	if !found {
+		return fmt.Errorf("unknown dmg palette (%s)", palette)
+	}
+
+	gb.GPU().SetDMGPalettes(p)
+
+	return nil
+}
+
+// stateFile returns the save state file name for the given rom and slot
+func stateFile(romFile string, slot int) string {
+	return fmt.Sprintf("%s.ss%d", strings.TrimSuffix(romFile, filepath.Ext(romFile)), slot)
+}
+
+// saveState of the gameboy to file, the state is written to a temporary
+// file first so a failed save won't destroy the previous state of the slot
+func saveState(gb *gameboy.Gameboy, filename string) error {
+
+	tmp := filename + ".tmp"
+
+	if err := writeState(gb, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
+
+// writeState of the gameboy to a new file
+func writeState(gb *gameboy.Gameboy, filename string) error {
+
+	f, err := os.Create(filename)
+
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+
+	if err := gb.SaveState(w); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// loadState of the gameboy from file
+func loadState(gb *gameboy.Gameboy, filename string) error {
+
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if err := gb.LoadState(bufio.NewReader(f)); err != nil {
+		return err
+	}
+
+	// show the loaded frame (e.g. while paused)
+	return gb.Core().Exec(gb.GPU().Redraw)
+}
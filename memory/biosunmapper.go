@@ -1,7 +1,11 @@
 package memory
 
+// AddrBootROM is the address of the boot rom disable register
+const AddrBootROM uint16 = 0xFF50
+
 // BiosUnmapper memory unit is used to unmap the 256B bios from
-// 0x0000 and map the game cartridge when the bios execution ends
+// 0x0000 (and the rest of the cgb bios from 0x0200) and map the
+// game cartridge when the bios execution ends
 type BiosUnmapper struct {
 	mmu       *MMU
 	cartridge Unit
@@ -18,15 +22,16 @@ func (b *BiosUnmapper) Read(addr uint16) (byte, error) {
 	return 0, ReadAccessViolationError(addr)
 }
 
-// Write will unmap the 256B bios from 0x0000 and map the game cartridge instead
+// Write will unmap the bios and map the game cartridge instead
 func (b *BiosUnmapper) Write(addr uint16, data byte) error {
 
-	if addr != 0xFF50 {
+	if addr != AddrBootROM {
 		return WriteAccessViolationError(addr)
 	}
 
-	if data == 0x01 {
+	if data&0x01 == 0x01 {
 		b.mmu.Map(b.cartridge, 0x0000, 0x00FF)
+		b.mmu.Map(b.cartridge, 0x0200, 0x08FF)
 	}
 
 	return nil
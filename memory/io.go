@@ -0,0 +1,70 @@
+package memory
+
+// IOStart is the first address of the i/o registers
+const IOStart uint16 = 0xFF00
+
+// IOEnd is the last address of the i/o registers
+const IOEnd uint16 = 0xFF7F
+
+// IO is the i/o registers area, every register is accessed through the
+// unit that implements it, with its unused bits read as 1, unmapped
+// addresses read 0xFF and ignore writes
+type IO struct {
+	units [IOEnd - IOStart + 1]Unit
+	masks [IOEnd - IOStart + 1]byte
+}
+
+// NewIO creates IO instance over the units that are currently mapped to
+// IOStart -> IOEnd in 'mmu', 'masks' holds the bits that always read 1
+// for each register (0xFF for a write only register), addresses without
+// a mask are left unmapped
+func NewIO(mmu *MMU, masks map[uint16]byte) *IO {
+
+	var io IO
+
+	for i := range io.units {
+
+		addr := IOStart + uint16(i)
+		io.masks[i] = 0xFF
+
+		if mask, found := masks[addr]; found {
+			io.units[i] = mmu.Unit(addr)
+			io.masks[i] = mask
+		}
+	}
+
+	return &io
+}
+
+// Read from register 'addr'
+func (io *IO) Read(addr uint16) (byte, error) {
+
+	if addr < IOStart || addr > IOEnd {
+		return 0, ReadOutOfRangeError(addr)
+	}
+
+	i := addr - IOStart
+	unit := io.units[i]
+
+	if unit == nil || io.masks[i] == 0xFF {
+		return 0xFF, nil
+	}
+
+	data, err := unit.Read(addr)
+
+	return data | io.masks[i], err
+}
+
+// Write 'data' to register 'addr'
+func (io *IO) Write(addr uint16, data byte) error {
+
+	if addr < IOStart || addr > IOEnd {
+		return WriteOutOfRangeError(addr)
+	}
+
+	if unit := io.units[addr-IOStart]; unit != nil {
+		return unit.Write(addr, data)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import "testing"
+
+// TestIO maps a ram over the i/o area and checks the masked reads
+// and the writes through an IO unit
+func TestIO(t *testing.T) {
+
+	mmu := NewMMU()
+	ram := NewRAM(make([]byte, int(IOEnd-IOStart)+1), IOStart)
+
+	if err := mmu.Map(ram, IOStart, IOEnd); err != nil {
+		t.Fatal(err)
+	}
+
+	io := NewIO(mmu, map[uint16]byte{
+		0xFF00: 0x00,
+		0xFF01: 0xF0,
+		0xFF02: 0xFF})
+
+	tests := []struct {
+		name    string
+		addr    uint16
+		write   byte
+		want    byte
+		written byte
+	}{
+		{"no mask", 0xFF00, 0x12, 0x12, 0x12},
+		{"unused bits", 0xFF01, 0x05, 0xF5, 0x05},
+		{"write only", 0xFF02, 0x34, 0xFF, 0x34},
+		{"unmapped", 0xFF03, 0x56, 0xFF, 0x00},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			if err := io.Write(test.addr, test.write); err != nil {
+				t.Fatal(err)
+			}
+
+			if data, err := io.Read(test.addr); err != nil || data != test.want {
+				t.Errorf("read %02x (%v), want %02x", data, err, test.want)
+			}
+
+			if data, _ := ram.Read(test.addr); data != test.written {
+				t.Errorf("the register holds %02x, want %02x", data, test.written)
+			}
+		})
+	}
+
+	if _, err := io.Read(IOEnd + 1); err == nil {
+		t.Error("read out of range succeeded")
+	}
+}
@@ -5,9 +5,23 @@ import (
 	"os"
 )
 
+// Watchpoint is triggered when an address in the range
+// From -> To is read (Read) or written (Write)
+type Watchpoint struct {
+	From  uint16
+	To    uint16
+	Read  bool
+	Write bool
+}
+
+// WatchHandler is called when a watchpoint is triggered
+type WatchHandler func(wp Watchpoint, addr uint16, data byte, write bool)
+
 // MMU is the gateway for all other memory units
 type MMU struct {
-	mapping []Unit
+	mapping     []Unit
+	watchpoints []Watchpoint
+	onWatch     WatchHandler
 }
 
 // NewMMU creates MMU instance
@@ -63,7 +77,13 @@ func (m *MMU) Map(unit Unit, from, to uint16) error {
 	return nil
 }
 
-// Read from address 'addr'
+// Unit returns the memory unit mapped to address 'addr' (or nil)
+func (m *MMU) Unit(addr uint16) Unit {
+	return m.mapping[addr]
+}
+
+// Read from address 'addr', the access is not watched (units
+// that access the memory on their own, such as dma, use it)
 func (m *MMU) Read(addr uint16) (byte, error) {
 
 	if uint(len(m.mapping)) <= uint(addr) {
@@ -77,7 +97,30 @@ func (m *MMU) Read(addr uint16) (byte, error) {
 	return m.mapping[addr].Read(addr)
 }
 
-// Write 'data' to address 'addr'
+// ReadWatched reads from address 'addr' and triggers the
+// matching read watchpoints (the cpu data reads use it)
+func (m *MMU) ReadWatched(addr uint16) (byte, error) {
+
+	data, err := m.Read(addr)
+
+	if len(m.watchpoints) > 0 && err == nil {
+		m.watched(addr, data, false)
+	}
+
+	return data, err
+}
+
+// Peek reads from address 'addr' without triggering watchpoints
+func (m *MMU) Peek(addr uint16) (byte, error) {
+
+	if m.mapping[addr] == nil {
+		return 0, ReadAccessViolationError(addr)
+	}
+
+	return m.mapping[addr].Read(addr)
+}
+
+// Write 'data' to address 'addr', the access is not watched
 func (m *MMU) Write(addr uint16, data byte) error {
 
 	if uint(len(m.mapping)) <= uint(addr) {
@@ -90,3 +133,66 @@ func (m *MMU) Write(addr uint16, data byte) error {
 
 	return m.mapping[addr].Write(addr, data)
 }
+
+// WriteWatched writes 'data' to address 'addr' and triggers the
+// matching write watchpoints (the cpu data writes use it)
+func (m *MMU) WriteWatched(addr uint16, data byte) error {
+
+	if len(m.watchpoints) > 0 && uint(addr) < uint(len(m.mapping)) && m.mapping[addr] != nil {
+		m.watched(addr, data, true)
+	}
+
+	return m.Write(addr, data)
+}
+
+// SetWatchHandler sets the function that is called
+// when a watchpoint is triggered
+func (m *MMU) SetWatchHandler(handler WatchHandler) {
+	m.onWatch = handler
+}
+
+// Watch adds a watchpoint (replaces a watchpoint with the same 'From')
+func (m *MMU) Watch(wp Watchpoint) error {
+
+	if wp.From > wp.To {
+		return fmt.Errorf("invalid watchpoint from: %04x to %04x", wp.From, wp.To)
+	}
+
+	m.Unwatch(wp.From)
+	m.watchpoints = append(m.watchpoints, wp)
+
+	return nil
+}
+
+// Unwatch removes the watchpoint that starts at 'from'
+func (m *MMU) Unwatch(from uint16) bool {
+
+	for i, wp := range m.watchpoints {
+		if wp.From == from {
+			m.watchpoints = append(m.watchpoints[:i], m.watchpoints[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Watchpoints returns the current watchpoints
+func (m *MMU) Watchpoints() []Watchpoint {
+	return append([]Watchpoint(nil), m.watchpoints...)
+}
+
+// watched calls the watch handler for every watchpoint that
+// covers address 'addr'
+func (m *MMU) watched(addr uint16, data byte, write bool) {
+
+	if m.onWatch == nil {
+		return
+	}
+
+	for _, wp := range m.watchpoints {
+		if wp.From <= addr && addr <= wp.To && ((write && wp.Write) || (!write && wp.Read)) {
+			m.onWatch(wp, addr, data, write)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"fmt"
+	"io"
 )
 
 // RAM is a read/write memory unit
@@ -66,3 +67,15 @@ func (r *RAM) Write(addr uint16, data byte) error {
 
 	return nil
 }
+
+// SaveState writes the ram content to 'w'
+func (r *RAM) SaveState(w io.Writer) error {
+	_, err := w.Write(r.data)
+	return err
+}
+
+// LoadState reads the ram content from 'r'
+func (r *RAM) LoadState(rd io.Reader) error {
+	_, err := io.ReadFull(rd, r.data)
+	return err
+}
@@ -0,0 +1,76 @@
+package memory
+
+import "io"
+
+// AddrSVBK is the address of the wram bank register (cgb)
+const AddrSVBK uint16 = 0xFF70
+
+// WRAMBankSize is the size of a single wram bank
+const WRAMBankSize = 4096
+
+// WRAMBanker is the SVBK register, it selects the wram bank (1 - 7) that
+// is mapped to D000-DFFF, 'ram' holds banks 1 - 7 and is viewed through
+// a window at the selected bank
+type WRAMBanker struct {
+	ram  *RAM
+	bank byte
+}
+
+// NewWRAMBanker creates WRAMBanker instance over banks 1 - 7
+func NewWRAMBanker(ram *RAM) *WRAMBanker {
+	return &WRAMBanker{ram: ram}
+}
+
+// Read the selected bank
+func (w *WRAMBanker) Read(addr uint16) (byte, error) {
+
+	if addr != AddrSVBK {
+		return 0, ReadOutOfRangeError(addr)
+	}
+
+	return w.bank, nil
+}
+
+// Write selects the bank (0 selects bank 1)
+func (w *WRAMBanker) Write(addr uint16, data byte) error {
+
+	if addr != AddrSVBK {
+		return WriteOutOfRangeError(addr)
+	}
+
+	w.bank = data & 0x07
+
+	return w.apply()
+}
+
+// apply moves the ram window to the selected bank
+func (w *WRAMBanker) apply() error {
+
+	bank := uint32(w.bank)
+
+	if bank == 0 {
+		bank = 1
+	}
+
+	return w.ram.SetWindow((bank - 1) * WRAMBankSize)
+}
+
+// SaveState writes the selected bank to 'w'
+func (w *WRAMBanker) SaveState(wr io.Writer) error {
+	_, err := wr.Write([]byte{w.bank})
+	return err
+}
+
+// LoadState reads the selected bank from 'r'
+func (w *WRAMBanker) LoadState(r io.Reader) error {
+
+	var data [1]byte
+
+	if _, err := io.ReadFull(r, data[:]); err != nil {
+		return err
+	}
+
+	w.bank = data[0] & 0x07
+
+	return w.apply()
+}
@@ -0,0 +1,378 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/moshenahmias/gopherboy/display"
+)
+
+// aviFrameSize is the size of an uncompressed 24 bit frame
+const aviFrameSize = display.ScreenWidth * display.ScreenHeight * 3
+
+// aviMaxSize is the size limit of a riff avi file (avi 1.0 readers
+// don't support files over 1GB, the 32 bit sizes wrap at 4GB)
+const aviMaxSize = 1000 * 1024 * 1024
+
+// errAVISizeLimit is returned when the next frame won't fit in the file
+var errAVISizeLimit = errors.New("the avi file size limit (1GB) was reached")
+
+// aviMainHeader is the avih chunk
+type aviMainHeader struct {
+	MicroSecPerFrame    uint32
+	MaxBytesPerSec      uint32
+	PaddingGranularity  uint32
+	Flags               uint32
+	TotalFrames         uint32
+	InitialFrames       uint32
+	Streams             uint32
+	SuggestedBufferSize uint32
+	Width               uint32
+	Height              uint32
+	Reserved            [4]uint32
+}
+
+// aviStreamHeader is the strh chunk
+type aviStreamHeader struct {
+	Type                [4]byte
+	Handler             [4]byte
+	Flags               uint32
+	Priority            uint16
+	Language            uint16
+	InitialFrames       uint32
+	Scale               uint32
+	Rate                uint32
+	Start               uint32
+	Length              uint32
+	SuggestedBufferSize uint32
+	Quality             uint32
+	SampleSize          uint32
+	Frame               [4]int16
+}
+
+// bitmapInfoHeader is the strf chunk of the video stream
+type bitmapInfoHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32
+	Planes        uint16
+	BitCount      uint16
+	Compression   uint32
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ClrUsed       uint32
+	ClrImportant  uint32
+}
+
+// waveFormat is the strf chunk of the audio stream
+type waveFormat struct {
+	FormatTag      uint16
+	Channels       uint16
+	SamplesPerSec  uint32
+	AvgBytesPerSec uint32
+	BlockAlign     uint16
+	BitsPerSample  uint16
+}
+
+// aviIndexEntry is a single idx1 entry
+type aviIndexEntry struct {
+	ID     [4]byte
+	Flags  uint32
+	Offset uint32
+	Size   uint32
+}
+
+const (
+	aviHasIndex  uint32 = 0x10
+	aviKeyFrame  uint32 = 0x10
+	aviQualityNA uint32 = 0xFFFFFFFF
+)
+
+var (
+	aviVideoChunk = [4]byte{'0', '0', 'd', 'b'}
+	aviAudioChunk = [4]byte{'0', '1', 'w', 'b'}
+)
+
+// avi writes the frames (uncompressed 24 bit) and the samples
+// (8 bit pcm) to a riff avi file, one audio chunk per frame
+type avi struct {
+	f          *os.File
+	w          *bufio.Writer
+	colors     [4]uint32
+	rate       int
+	frames     uint32
+	audioBytes uint32
+	moviSize   uint32 // size of the chunks in the movi list
+	headerSize int    // size of everything before the movi chunks
+	index      []aviIndexEntry
+	audio      []byte // samples since the last frame
+	pixels     [aviFrameSize]byte
+}
+
+// newAVI creates the avi file
+func newAVI(file string, colors [4]uint32, rate int) (*avi, error) {
+
+	f, err := os.Create(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &avi{f: f, w: bufio.NewWriter(f), colors: colors, rate: rate}
+
+	// the counters and sizes are written again on close
+	h := e.header()
+
+	if _, err := e.w.Write(h); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	e.headerSize = len(h)
+
+	return e, nil
+}
+
+// frame writes the frame (bottom-up rows) followed by the samples that
+// were queued since the previous frame, returns errAVISizeLimit (and
+// writes nothing) if the file would grow over aviMaxSize
+func (e *avi) frame(f *display.Frame) error {
+
+	// the video and audio chunks and their index entries
+	next := int64(8+aviFrameSize) + int64(8+len(e.audio)) + 2*16
+
+	if e.size()+next > aviMaxSize {
+		return errAVISizeLimit
+	}
+
+	for y := 0; y < display.ScreenHeight; y++ {
+
+		row := (display.ScreenHeight - 1 - y) * display.ScreenWidth * 3
+
+		for x := 0; x < display.ScreenWidth; x++ {
+
+			c := f[x][y].RGB(e.colors)
+			i := row + x*3
+
+			e.pixels[i] = byte(c)
+			e.pixels[i+1] = byte(c >> 8)
+			e.pixels[i+2] = byte(c >> 16)
+		}
+	}
+
+	if err := e.chunk(aviVideoChunk, e.pixels[:]); err != nil {
+		return err
+	}
+
+	e.frames++
+
+	return e.flushAudio()
+}
+
+// samples keeps the samples until the next frame
+func (e *avi) samples(s []byte) error {
+
+	e.audio = append(e.audio, s...)
+
+	return nil
+}
+
+// flushAudio writes the pending samples as a single chunk
+func (e *avi) flushAudio() error {
+
+	// keep whole stereo pairs
+	n := len(e.audio) &^ 1
+
+	if n == 0 {
+		return nil
+	}
+
+	if err := e.chunk(aviAudioChunk, e.audio[:n]); err != nil {
+		return err
+	}
+
+	e.audioBytes += uint32(n)
+	e.audio = e.audio[:copy(e.audio, e.audio[n:])]
+
+	return nil
+}
+
+// chunk writes a chunk to the movi list and indexes it
+func (e *avi) chunk(id [4]byte, data []byte) error {
+
+	// offsets are relative to the 'movi' fourcc
+	e.index = append(e.index, aviIndexEntry{
+		ID:     id,
+		Flags:  aviKeyFrame,
+		Offset: 4 + e.moviSize,
+		Size:   uint32(len(data))})
+
+	if _, err := e.w.Write(id[:]); err != nil {
+		return err
+	}
+
+	if err := binary.Write(e.w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+
+	e.moviSize += 8 + uint32(len(data))
+
+	return nil
+}
+
+// close writes the index and the final header
+func (e *avi) close() error {
+
+	err := e.finish()
+
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// finish writes the remaining samples, the index and the final header
+func (e *avi) finish() error {
+
+	if err := e.flushAudio(); err != nil {
+		return err
+	}
+
+	if _, err := e.w.Write([]byte("idx1")); err != nil {
+		return err
+	}
+
+	if err := binary.Write(e.w, binary.LittleEndian, uint32(len(e.index)*16)); err != nil {
+		return err
+	}
+
+	if err := binary.Write(e.w, binary.LittleEndian, e.index); err != nil {
+		return err
+	}
+
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := e.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err := e.f.Write(e.header())
+
+	return err
+}
+
+// size returns the current size of the file (including the index)
+func (e *avi) size() int64 {
+	return int64(e.headerSize) + int64(e.moviSize) + 8 + int64(len(e.index)*16)
+}
+
+// header returns everything before the movi chunks, with the current counters
+func (e *avi) header() []byte {
+
+	le := binary.LittleEndian
+
+	var hdrl bytes.Buffer
+
+	hdrl.WriteString("hdrl")
+
+	writeChunk(&hdrl, "avih", &aviMainHeader{
+		MicroSecPerFrame:    uint32(uint64(frameRate[1]) * 1000000 / uint64(frameRate[0])),
+		MaxBytesPerSec:      uint32(uint64(aviFrameSize)*uint64(frameRate[0])/uint64(frameRate[1])) + uint32(e.rate*2),
+		Flags:               aviHasIndex,
+		TotalFrames:         e.frames,
+		Streams:             2,
+		SuggestedBufferSize: uint32(aviFrameSize),
+		Width:               uint32(display.ScreenWidth),
+		Height:              uint32(display.ScreenHeight)})
+
+	// video stream
+	var strl bytes.Buffer
+
+	strl.WriteString("strl")
+
+	writeChunk(&strl, "strh", &aviStreamHeader{
+		Type:                [4]byte{'v', 'i', 'd', 's'},
+		Handler:             [4]byte{'D', 'I', 'B', ' '},
+		Scale:               frameRate[1],
+		Rate:                frameRate[0],
+		Length:              e.frames,
+		SuggestedBufferSize: uint32(aviFrameSize),
+		Quality:             aviQualityNA,
+		Frame:               [4]int16{0, 0, int16(display.ScreenWidth), int16(display.ScreenHeight)}})
+
+	writeChunk(&strl, "strf", &bitmapInfoHeader{
+		Size:      40,
+		Width:     int32(display.ScreenWidth),
+		Height:    int32(display.ScreenHeight), // bottom-up
+		Planes:    1,
+		BitCount:  24,
+		SizeImage: uint32(aviFrameSize)})
+
+	writeList(&hdrl, strl.Bytes())
+
+	// audio stream
+	strl.Reset()
+	strl.WriteString("strl")
+
+	writeChunk(&strl, "strh", &aviStreamHeader{
+		Type:                [4]byte{'a', 'u', 'd', 's'},
+		Scale:               2,
+		Rate:                uint32(e.rate * 2),
+		Length:              e.audioBytes / 2,
+		SuggestedBufferSize: uint32(e.rate * 2),
+		Quality:             aviQualityNA,
+		SampleSize:          2})
+
+	writeChunk(&strl, "strf", &waveFormat{
+		FormatTag:      1, // pcm
+		Channels:       2,
+		SamplesPerSec:  uint32(e.rate),
+		AvgBytesPerSec: uint32(e.rate * 2),
+		BlockAlign:     2,
+		BitsPerSample:  8})
+
+	writeList(&hdrl, strl.Bytes())
+
+	var h bytes.Buffer
+
+	h.WriteString("RIFF")
+	binary.Write(&h, le, uint32(0)) // set below
+	h.WriteString("AVI ")
+	writeList(&h, hdrl.Bytes())
+	h.WriteString("LIST")
+	binary.Write(&h, le, 4+e.moviSize)
+	h.WriteString("movi")
+
+	// everything after the riff size, including the index
+	le.PutUint32(h.Bytes()[4:], uint32(h.Len())-8+e.moviSize+8+uint32(len(e.index)*16))
+
+	return h.Bytes()
+}
+
+// writeChunk writes a chunk with the little endian encoding of 'data'
+func writeChunk(b *bytes.Buffer, id string, data interface{}) {
+
+	b.WriteString(id)
+	binary.Write(b, binary.LittleEndian, uint32(binary.Size(data)))
+	binary.Write(b, binary.LittleEndian, data)
+}
+
+// writeList writes a list chunk, 'data' starts with the list type
+func writeList(b *bytes.Buffer, data []byte) {
+
+	b.WriteString("LIST")
+	binary.Write(b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+}
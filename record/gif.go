@@ -0,0 +1,150 @@
+package record
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+
+	"github.com/moshenahmias/gopherboy/display"
+)
+
+// gifFrameStep is the number of frames per gif frame, most viewers
+// don't show frames shorter than 2/100 of a second
+const gifFrameStep = 2
+
+// gifMaxFrames limits the length of a gif recording (about 3 minutes),
+// the frames are kept in memory until the recording stops
+const gifMaxFrames = 5400
+
+// errGIFFrameLimit is returned when a frame is recorded after gifMaxFrames,
+// the recording stops and the kept frames are written
+var errGIFFrameLimit = fmt.Errorf("the gif length limit (%d frames) was reached", gifMaxFrames)
+
+// gifEncoder keeps every other frame and writes an animated gif on close,
+// the samples are ignored
+type gifEncoder struct {
+	file    string
+	colors  [4]uint32
+	anim    gif.GIF
+	frames  int // frames since the recording started
+	delay   int // total delay of the kept frames (1/100 seconds)
+	indices map[uint32]uint8
+}
+
+// newGIF creates the gif file (written on close)
+func newGIF(file string, colors [4]uint32) (*gifEncoder, error) {
+
+	// make sure the file can be created before recording
+	f, err := os.Create(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.Close()
+
+	return &gifEncoder{file: file, colors: colors, indices: make(map[uint32]uint8)}, nil
+}
+
+// frame keeps the frame, delayed until the next kept frame
+func (e *gifEncoder) frame(f *display.Frame) error {
+
+	if len(e.anim.Image) >= gifMaxFrames {
+		return errGIFFrameLimit
+	}
+
+	e.frames++
+
+	if (e.frames-1)%gifFrameStep != 0 {
+		return nil
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, display.ScreenWidth, display.ScreenHeight), nil)
+
+	// a palette of the frame's colors (the nearest color when full)
+	for k := range e.indices {
+		delete(e.indices, k)
+	}
+
+	for x := 0; x < display.ScreenWidth; x++ {
+		for y := 0; y < display.ScreenHeight; y++ {
+
+			c := f[x][y].RGB(e.colors)
+			i, found := e.indices[c]
+
+			if !found {
+
+				if len(img.Palette) < 256 {
+					i = uint8(len(img.Palette))
+					img.Palette = append(img.Palette, rgba(c))
+				} else {
+					i = uint8(img.Palette.Index(rgba(c)))
+				}
+
+				e.indices[c] = i
+			}
+
+			img.SetColorIndex(x, y, i)
+		}
+	}
+
+	e.setLastDelay()
+
+	e.anim.Image = append(e.anim.Image, img)
+	e.anim.Delay = append(e.anim.Delay, 0)
+
+	return nil
+}
+
+// setLastDelay sets the delay of the last kept frame, so the total
+// delay follows the frames that were shown since the recording started
+func (e *gifEncoder) setLastDelay() {
+
+	n := len(e.anim.Delay)
+
+	if n == 0 {
+		return
+	}
+
+	// the time of the current frame (1/100 seconds, rounded)
+	t := int((uint64(e.frames-1)*uint64(frameRate[1])*100 + uint64(frameRate[0])/2) / uint64(frameRate[0]))
+
+	e.anim.Delay[n-1] = t - e.delay
+	e.delay = t
+}
+
+// samples are not recorded
+func (e *gifEncoder) samples(s []byte) error {
+	return nil
+}
+
+// close writes the animation to the file
+func (e *gifEncoder) close() error {
+
+	if len(e.anim.Image) == 0 {
+		return nil
+	}
+
+	e.frames++
+	e.setLastDelay()
+
+	f, err := os.Create(e.file)
+
+	if err != nil {
+		return err
+	}
+
+	if err := gif.EncodeAll(f, &e.anim); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// rgba converts 0x00RRGGBB to an opaque color
+func rgba(c uint32) color.RGBA {
+	return color.RGBA{R: byte(c >> 16), G: byte(c >> 8), B: byte(c), A: 0xFF}
+}
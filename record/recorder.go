@@ -0,0 +1,313 @@
+// Package record writes the frames and sound samples of a running game
+// to video files (uncompressed avi, y4m + wav or animated gif)
+package record
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/moshenahmias/gopherboy/audio"
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/display"
+)
+
+// ErrUnknownFormat is returned for an unsupported file extension
+var ErrUnknownFormat = errors.New("ErrUnknownFormat")
+
+// frameRate is the number of frames per second, as a
+// fraction (cpu cycles per second / cpu cycles per frame)
+var frameRate = [2]uint32{uint32(cpu.Frequency), uint32(cpu.FrameCycles)}
+
+// defaultSampleRate is used when there is no audioer to tap
+const defaultSampleRate int = 44100
+
+// encoder writes a recording in a single format, the samples are
+// 8 bit unsigned stereo pairs (left, right)
+type encoder interface {
+	frame(f *display.Frame) error
+	samples(s []byte) error
+	close() error
+}
+
+// Recorder taps the frames sent to a monitor and the samples
+// queued to an audioer and writes them to a file
+type Recorder struct {
+	m       sync.Mutex
+	colors  [4]uint32
+	rate    int
+	enc     encoder
+	file    string
+	base    string                       // the file name given to Start
+	part    int                          // the current part of an avi recording
+	onSplit func(file string, why error) // called when a recording continues in a new file
+	onError func(file string, err error) // called when a recording stops on error
+}
+
+// NewRecorder creates Recorder instance, 'colors' holds the
+// 0x00RRGGBB values of the 4 pixel colors
+func NewRecorder(colors [4]uint32) *Recorder {
+	return &Recorder{colors: colors, rate: defaultSampleRate}
+}
+
+// Monitor returns a monitor that records every frame and draws it on 'm'
+func (r *Recorder) Monitor(m display.Monitor) display.Monitor {
+	return &monitorTap{Monitor: m, r: r}
+}
+
+// Audioer returns an audioer that records every sample and queues it to 'a'
+func (r *Recorder) Audioer(a audio.Audioer) audio.Audioer {
+
+	r.m.Lock()
+	r.rate = a.Frequency()
+	r.m.Unlock()
+
+	return &audioTap{Audioer: a, r: r}
+}
+
+// SetSplitHandler sets the function that is called when a recording
+// continues in a new file (an avi file reached its size limit), 'file'
+// is the new file and 'why' is the reason of the split
+func (r *Recorder) SetSplitHandler(handler func(file string, why error)) {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.onSplit = handler
+}
+
+// SetErrorHandler sets the function that is called when a recording
+// stops because of a write error (e.g. a full disk), 'file' is the
+// recording file, the emulation continues without recording
+func (r *Recorder) SetErrorHandler(handler func(file string, err error)) {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.onError = handler
+}
+
+// Start recording to 'file', the format is chosen by
+// the file extension (.avi, .y4m or .gif)
+func (r *Recorder) Start(file string) error {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.enc != nil {
+		if err := r.stop(); err != nil {
+			return err
+		}
+	}
+
+	var enc encoder
+	var err error
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".avi":
+		enc, err = newAVI(file, r.colors, r.rate)
+	case ".y4m":
+		enc, err = newY4M(file, r.colors, r.rate)
+	case ".gif":
+		enc, err = newGIF(file, r.colors)
+	default:
+		return ErrUnknownFormat
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r.enc = enc
+	r.file = file
+	r.base = file
+	r.part = 1
+
+	return nil
+}
+
+// split closes the current avi file and continues the
+// recording in the next part (<name>-<part>.avi) of the file
+func (r *Recorder) split(why error) error {
+
+	// the pending samples belong to the next frame, in the next file
+	var pending []byte
+
+	if e, ok := r.enc.(*avi); ok {
+		pending, e.audio = e.audio, nil
+	}
+
+	if err := r.stop(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.base)
+	file := strings.TrimSuffix(r.base, ext) + "-" + strconv.Itoa(r.part+1) + ext
+
+	enc, err := newAVI(file, r.colors, r.rate)
+
+	if err != nil {
+		return err
+	}
+
+	enc.audio = pending
+
+	r.enc = enc
+	r.file = file
+	r.part++
+
+	if r.onSplit != nil {
+		r.onSplit(file, why)
+	}
+
+	return nil
+}
+
+// Stop recording and close the file
+func (r *Recorder) Stop() error {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.stop()
+}
+
+// stop recording (the lock is held)
+func (r *Recorder) stop() error {
+
+	if r.enc == nil {
+		return nil
+	}
+
+	err := r.enc.close()
+	r.enc = nil
+
+	return err
+}
+
+// Recording returns true while recording
+func (r *Recorder) Recording() bool {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.enc != nil
+}
+
+// File returns the name of the last recording file
+func (r *Recorder) File() string {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.file
+}
+
+// fail stops the recording after a write error and reports it
+// to the error handler (the lock is held)
+func (r *Recorder) fail(err error) {
+
+	if stopErr := r.stop(); stopErr != nil {
+		err = fmt.Errorf("%v (%v)", err, stopErr)
+	}
+
+	if r.onError != nil {
+		r.onError(r.file, err)
+	}
+}
+
+// frame records a frame, the recording stops on error
+func (r *Recorder) frame(f *display.Frame) error {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.enc == nil {
+		return nil
+	}
+
+	err := r.enc.frame(f)
+
+	// continue in a new file
+	if err == errAVISizeLimit {
+
+		if err = r.split(err); err == nil {
+			err = r.enc.frame(f)
+		}
+	}
+
+	if err != nil {
+		r.fail(err)
+	}
+
+	return nil
+}
+
+// samples records sound samples, the recording stops on error
+func (r *Recorder) samples(s []byte) error {
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.enc == nil {
+		return nil
+	}
+
+	if err := r.enc.samples(s); err != nil {
+		r.fail(err)
+	}
+
+	return nil
+}
+
+// monitorTap records the frames before drawing them
+type monitorTap struct {
+	display.Monitor
+	r *Recorder
+}
+
+// DrawFrame records the frame and draws it
+func (t *monitorTap) DrawFrame(f *display.Frame) error {
+
+	if err := t.r.frame(f); err != nil {
+		return err
+	}
+
+	return t.Monitor.DrawFrame(f)
+}
+
+// DrawBorder draws the border on the monitor (if supported), the
+// border is not recorded
+func (t *monitorTap) DrawBorder(b *display.Border) error {
+
+	if d, ok := t.Monitor.(display.BorderDrawer); ok {
+		return d.DrawBorder(b)
+	}
+
+	return nil
+}
+
+// audioTap records the samples before queuing them
+type audioTap struct {
+	audio.Audioer
+	r *Recorder
+}
+
+// Queue records the samples and queues them
+func (t *audioTap) Queue(samples []byte) error {
+
+	if err := t.r.samples(samples); err != nil {
+		return err
+	}
+
+	return t.Audioer.Queue(samples)
+}
+
+// FileName returns a recording file name in 'dir', named after
+// 'title' and the current time, 'ext' selects the format (e.g. ".avi")
+func FileName(dir, title, ext string) string {
+	return display.FileName(dir, title, "recording", ext)
+}
@@ -0,0 +1,101 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moshenahmias/gopherboy/audio"
+	"github.com/moshenahmias/gopherboy/display"
+)
+
+// y4m writes the frames to a yuv4mpeg2 file (uncompressed 4:4:4)
+// and the samples to a wav file with the same name
+type y4m struct {
+	f      *os.File
+	w      *bufio.Writer
+	wav    *audio.WAV
+	colors [4]uint32
+	plane  [3][display.ScreenWidth * display.ScreenHeight]byte
+}
+
+// newY4M creates the y4m file and its wav file
+func newY4M(file string, colors [4]uint32, rate int) (*y4m, error) {
+
+	f, err := os.Create(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wav, err := audio.NewWAV(strings.TrimSuffix(file, filepath.Ext(file))+".wav", rate, 2)
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	e := &y4m{f: f, w: bufio.NewWriter(f), wav: wav, colors: colors}
+
+	// full range (jpeg) yuv values
+	if _, err := fmt.Fprintf(e.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444 XCOLORRANGE=FULL\n",
+		display.ScreenWidth, display.ScreenHeight, frameRate[0], frameRate[1]); err != nil {
+		e.close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// frame writes the frame's y, cb and cr planes
+func (e *y4m) frame(f *display.Frame) error {
+
+	for y := 0; y < display.ScreenHeight; y++ {
+		for x := 0; x < display.ScreenWidth; x++ {
+
+			c := f[x][y].RGB(e.colors)
+			i := y*display.ScreenWidth + x
+
+			e.plane[0][i], e.plane[1][i], e.plane[2][i] = color.RGBToYCbCr(byte(c>>16), byte(c>>8), byte(c))
+		}
+	}
+
+	if _, err := e.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+
+	for i := range e.plane {
+		if _, err := e.w.Write(e.plane[i][:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// samples writes the samples to the wav file
+func (e *y4m) samples(s []byte) error {
+
+	_, err := e.wav.Write(s)
+
+	return err
+}
+
+// close flushes and closes both files
+func (e *y4m) close() error {
+
+	err := e.w.Flush()
+
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+
+	if cerr := e.wav.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,488 @@
+package serial
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+)
+
+// LinkBudget is the default number of cycles a transfer that is clocked
+// by this side waits for the remote side's byte, before it completes with
+// 0xFF (the remote side is kept within this budget by the loose sync)
+const LinkBudget = 2 * cpu.FrameCycles
+
+// linkWait is how long Sync waits for the remote side before it returns
+// to the execution loop (which calls it again on the next instruction)
+const linkWait = 100 * time.Millisecond
+
+// linkVersion is the link protocol version
+const linkVersion byte = 2
+
+// link messages
+const (
+	msgHello    byte = iota + 1 // data: protocol version, cycles: random nonce
+	msgTransfer                 // data: byte sent by the clocking side
+	msgReply                    // data: byte sent back by the clocked side
+	msgCancel                   // the transfer ran out of cycles
+	msgSync                     // reports the sender's cycles
+)
+
+// link roles (negotiated by the hello messages)
+const (
+	roleNone   int32 = iota // the hello wasn't exchanged yet
+	roleSlave               // follows the master's cycles closely
+	roleMaster              // runs up to half a budget ahead of the slave
+)
+
+// message is a single link message (type, data and transfer sequence),
+// every message carries the sender's cycles since the connection
+type message struct {
+	Type   byte
+	Data   byte
+	Seq    uint16
+	Cycles uint64
+}
+
+// Link is a peer on the other side of a tcp connection (another gopherboy),
+// the hello messages negotiate the clock master (the higher random nonce
+// wins, the listening side breaks a tie), every message carries the
+// cycles of its sender and Sync keeps the slave close behind the master
+// (and the master less than half a budget ahead of the slave), so a
+// transfer is exchanged within a cycle budget without blocking the cpu,
+// when both sides drive the clock the bytes are swapped (as the hardware does)
+type Link struct {
+	m        sync.Mutex
+	conn     net.Conn
+	listener net.Listener
+	pending  []message     // transfers clocked by the remote side
+	replies  []message     // replies to transfers clocked by this side
+	notify   chan struct{} // signaled when a message arrives
+	seq      uint16        // sequence of the last sent transfer
+	waiting  bool          // a transfer clocked by this side waits for a reply
+	nonce    uint64        // the hello nonce of this side
+	role     int32         // roleNone, roleSlave or roleMaster (atomic)
+	cycles   uint64        // cycles of this side since the connection (atomic)
+	remote   uint64        // last reported cycles of the remote side (atomic)
+	reported uint64        // cycles of this side in the last sent message (atomic)
+	budget   int
+}
+
+// newLink creates an unconnected Link instance
+func newLink() *Link {
+	return &Link{notify: make(chan struct{}, 1), budget: LinkBudget}
+}
+
+// Listen for a remote side on 'addr', the link acts as
+// an unplugged cable until a connection is accepted
+func Listen(addr string) (*Link, error) {
+
+	listener, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := newLink()
+	l.listener = listener
+
+	go func() {
+
+		for {
+
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			l.attach(conn)
+		}
+	}()
+
+	return l, nil
+}
+
+// Dial the remote side at 'addr'
+func Dial(addr string) (*Link, error) {
+
+	conn, err := net.Dial("tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := newLink()
+	l.attach(conn)
+
+	return l, nil
+}
+
+// Addr returns the listening address (nil for a dialed link)
+func (l *Link) Addr() net.Addr {
+
+	if l.listener == nil {
+		return nil
+	}
+
+	return l.listener.Addr()
+}
+
+// Connected returns true if there is a remote side
+func (l *Link) Connected() bool {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.conn != nil
+}
+
+// Ready returns true once the remote side answered the hello
+func (l *Link) Ready() bool {
+	return atomic.LoadInt32(&l.role) != roleNone
+}
+
+// Master returns true if this side is the clock master
+func (l *Link) Master() bool {
+	return atomic.LoadInt32(&l.role) == roleMaster
+}
+
+// Close the connection (and the listener)
+func (l *Link) Close() error {
+
+	if l.listener != nil {
+		l.listener.Close()
+	}
+
+	l.m.Lock()
+	conn := l.conn
+	l.m.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+
+	return nil
+}
+
+// attach a new connection (replaces the current one)
+func (l *Link) attach(conn net.Conn) {
+
+	l.m.Lock()
+
+	if l.conn != nil {
+		l.conn.Close()
+	}
+
+	l.conn = conn
+	l.pending = nil
+	l.replies = nil
+	l.waiting = false
+	l.nonce = rand.Uint64()
+	nonce := l.nonce
+
+	atomic.StoreInt32(&l.role, roleNone)
+	atomic.StoreUint64(&l.cycles, 0)
+	atomic.StoreUint64(&l.remote, 0)
+	atomic.StoreUint64(&l.reported, 0)
+
+	l.m.Unlock()
+
+	l.send(conn, message{Type: msgHello, Data: linkVersion, Cycles: nonce})
+
+	go l.receive(conn)
+}
+
+// detach the connection after an error
+func (l *Link) detach(conn net.Conn) {
+
+	conn.Close()
+
+	l.m.Lock()
+
+	// the replies that already arrived are kept
+	if l.conn == conn {
+		l.conn = nil
+		l.pending = nil
+		atomic.StoreInt32(&l.role, roleNone)
+	}
+
+	l.m.Unlock()
+
+	l.signal()
+}
+
+// receive messages until the connection is closed
+func (l *Link) receive(conn net.Conn) {
+
+	defer l.detach(conn)
+
+	for {
+
+		var msg message
+
+		if err := binary.Read(conn, binary.BigEndian, &msg); err != nil {
+			return
+		}
+
+		l.m.Lock()
+
+		switch msg.Type {
+
+		case msgHello:
+
+			if msg.Data != linkVersion {
+				l.m.Unlock()
+				return
+			}
+
+			// the higher nonce drives the sync, the listening side breaks a tie
+			if l.nonce > msg.Cycles || (l.nonce == msg.Cycles && l.listener != nil) {
+				atomic.StoreInt32(&l.role, roleMaster)
+			} else {
+				atomic.StoreInt32(&l.role, roleSlave)
+			}
+
+			msg.Cycles = 0
+
+		case msgTransfer:
+
+			l.pending = append(l.pending, msg)
+
+		case msgReply:
+
+			l.replies = append(l.replies, msg)
+
+		case msgCancel:
+
+			for i, p := range l.pending {
+				if p.Seq == msg.Seq {
+					l.pending = append(l.pending[:i], l.pending[i+1:]...)
+					break
+				}
+			}
+		}
+
+		atomic.StoreUint64(&l.remote, msg.Cycles)
+
+		l.m.Unlock()
+
+		l.signal()
+	}
+}
+
+// signal that a message arrived
+func (l *Link) signal() {
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// send a message, stamped with the cycles of this side
+func (l *Link) send(conn net.Conn, msg message) error {
+
+	if msg.Type != msgHello {
+		msg.Cycles = atomic.LoadUint64(&l.cycles)
+		atomic.StoreUint64(&l.reported, msg.Cycles)
+	}
+
+	return binary.Write(conn, binary.BigEndian, &msg)
+}
+
+// connection returns the current connection (or nil)
+func (l *Link) connection() net.Conn {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.conn
+}
+
+// Transfer sends 'out' and returns the remote side's byte if it
+// already arrived (0xFF otherwise), the port uses Send and Answer
+// instead, to wait for the byte within the cycle budget
+func (l *Link) Transfer(out byte) byte {
+
+	l.Send(out)
+
+	if in, ok := l.Answer(); ok {
+		return in
+	}
+
+	l.Cancel()
+
+	return 0xFF
+}
+
+// Send 'out' to the remote side, a transfer clocked by this side started
+func (l *Link) Send(out byte) {
+
+	l.m.Lock()
+
+	conn := l.conn
+	l.seq++
+	seq := l.seq
+	l.waiting = conn != nil
+
+	l.m.Unlock()
+
+	if conn != nil {
+		l.send(conn, message{Type: msgTransfer, Data: out, Seq: seq})
+	}
+}
+
+// Answer returns the remote side's byte for the last sent transfer, if it
+// arrived (or 0xFF when disconnected), it never waits
+func (l *Link) Answer() (byte, bool) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if !l.waiting {
+		return 0xFF, true
+	}
+
+	for len(l.replies) > 0 {
+
+		msg := l.replies[0]
+		l.replies = l.replies[1:]
+
+		// drop replies to transfers that were canceled
+		if msg.Seq == l.seq {
+			l.waiting = false
+			return msg.Data, true
+		}
+	}
+
+	if l.conn == nil {
+		l.waiting = false
+		return 0xFF, true
+	}
+
+	// both sides drive the clock, swap the bytes
+	if len(l.pending) > 0 {
+
+		msg := l.pending[0]
+		l.pending = l.pending[1:]
+		l.waiting = false
+
+		return msg.Data, true
+	}
+
+	return 0, false
+}
+
+// Cancel the last sent transfer (it ran out of cycles)
+func (l *Link) Cancel() {
+
+	l.m.Lock()
+
+	conn := l.conn
+	seq := l.seq
+	waiting := l.waiting
+	l.waiting = false
+
+	l.m.Unlock()
+
+	if conn != nil && waiting {
+		l.send(conn, message{Type: msgCancel, Seq: seq})
+	}
+}
+
+// Poll completes a transfer that was clocked by the remote side
+func (l *Link) Poll(out byte) (byte, bool) {
+
+	l.m.Lock()
+
+	if len(l.pending) == 0 {
+		l.m.Unlock()
+		return 0xFF, false
+	}
+
+	conn := l.conn
+	msg := l.pending[0]
+	l.pending = l.pending[1:]
+
+	l.m.Unlock()
+
+	l.send(conn, message{Type: msgReply, Data: out, Seq: msg.Seq})
+
+	return msg.Data, true
+}
+
+// Budget returns the number of cycles a transfer waits for its answer
+func (l *Link) Budget() int {
+	return l.budget
+}
+
+// SetBudget sets the number of cycles a transfer waits for its
+// answer (LinkBudget by default), must be set before connecting
+func (l *Link) SetBudget(cycles int) {
+	l.budget = cycles
+}
+
+// lead returns the cycles this side may run ahead of the remote side
+func (l *Link) lead() uint64 {
+
+	if l.Master() {
+		return uint64(l.budget / 2)
+	}
+
+	return uint64(l.budget / 4)
+}
+
+// Clock counts the cycles of this side, the cycles are reported to the
+// remote side every eighth of the lead (called during the cpu step)
+func (l *Link) Clock(cycles int) {
+
+	now := atomic.AddUint64(&l.cycles, uint64(cycles))
+
+	if !l.Ready() || now-atomic.LoadUint64(&l.reported) < l.lead()/8 {
+		return
+	}
+
+	if conn := l.connection(); conn != nil {
+		l.send(conn, message{Type: msgSync})
+	}
+}
+
+// ahead returns true while this side is too far ahead of the remote side
+func (l *Link) ahead() bool {
+	return l.Ready() && atomic.LoadUint64(&l.cycles) > atomic.LoadUint64(&l.remote)+l.lead()
+}
+
+// Sync waits while this side is too far ahead of the remote side, up to
+// linkWait at a time (called between instructions, with the cpu unlocked)
+func (l *Link) Sync() {
+
+	if !l.ahead() {
+		return
+	}
+
+	conn := l.connection()
+
+	if conn == nil {
+		return
+	}
+
+	// the remote side might be waiting for this side's cycles
+	l.send(conn, message{Type: msgSync})
+
+	timeout := time.NewTimer(linkWait)
+	defer timeout.Stop()
+
+	for l.ahead() {
+
+		select {
+		case <-l.notify:
+		case <-timeout.C:
+			return
+		}
+	}
+}
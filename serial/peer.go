@@ -0,0 +1,98 @@
+package serial
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Peer is the device on the other side of the link cable, Transfer is
+// called when a transfer that is clocked by this side completes (sends
+// 'out', returns the received byte), Poll is called while this side waits
+// for the peer's clock (returns the received byte and true when the peer
+// completed a transfer, 'out' is sent)
+type Peer interface {
+	Transfer(out byte) byte
+	Poll(out byte) (byte, bool)
+}
+
+// Exchanger is a peer that answers the transfers clocked by this side
+// asynchronously (e.g. another gameboy over tcp), the port calls Send when
+// a transfer starts and completes it once Answer returns the peer's byte,
+// or with 0xFF (after Cancel) when Budget cycles passed without an answer,
+// Clock is called with the cycles of every step and Sync between steps,
+// with the cpu unlocked (Sync may wait for the peer to catch up)
+type Exchanger interface {
+	Peer
+	Send(out byte)
+	Answer() (byte, bool)
+	Cancel()
+	Budget() int
+	Clock(cycles int)
+	Sync()
+}
+
+// Disconnected is a peer for an unplugged link cable, every
+// received byte is 0xFF and the external clock never ticks
+type Disconnected struct {
+}
+
+// Transfer returns 0xFF
+func (Disconnected) Transfer(out byte) byte {
+	return 0xFF
+}
+
+// Poll always returns false
+func (Disconnected) Poll(out byte) (byte, bool) {
+	return 0xFF, false
+}
+
+// Loopback is a peer that connects the port's output to its input
+type Loopback struct {
+}
+
+// Transfer returns the sent byte
+func (Loopback) Transfer(out byte) byte {
+	return out
+}
+
+// Poll always returns false (there is no external clock)
+func (Loopback) Poll(out byte) (byte, bool) {
+	return 0xFF, false
+}
+
+// Sink is a disconnected peer that writes the sent bytes to a writer
+// (e.g. the output of blargg's test roms)
+type Sink struct {
+	Disconnected
+	w io.Writer
+}
+
+// NewSink creates Sink instance
+func NewSink(w io.Writer) *Sink {
+	return &Sink{w: w}
+}
+
+// Transfer writes 'out' and returns 0xFF
+func (s *Sink) Transfer(out byte) byte {
+	s.w.Write([]byte{out})
+	return 0xFF
+}
+
+// NewPeer creates a peer by name ("none", "loopback", "stdout" or
+// "printer", the printer saves its images to the working directory)
+func NewPeer(name string) (Peer, error) {
+
+	switch name {
+	case "", "none":
+		return Disconnected{}, nil
+	case "loopback":
+		return Loopback{}, nil
+	case "stdout":
+		return NewSink(os.Stdout), nil
+	case "printer":
+		return NewPrinter("."), nil
+	}
+
+	return nil, fmt.Errorf("no such serial peer (%s)", name)
+}
@@ -0,0 +1,383 @@
+package serial
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// printer commands
+const (
+	printerInit   byte = 0x01
+	printerPrint  byte = 0x02
+	printerData   byte = 0x04
+	printerStatus byte = 0x0F
+)
+
+// printer status flags
+const (
+	printerChecksumError byte = 0x01
+	printerPrinting      byte = 0x02
+	printerDataFull      byte = 0x04
+	printerUnprocessed   byte = 0x08
+)
+
+// printer packet parser states
+const (
+	stateMagic1 = iota
+	stateMagic2
+	stateCommand
+	stateCompression
+	stateLengthLow
+	stateLengthHigh
+	stateData
+	stateChecksumLow
+	stateChecksumHigh
+	stateAlive
+	stateStatus
+)
+
+// PrinterWidth of the printed image in pixels (20 tiles)
+const PrinterWidth int = 160
+
+// printerBufferSize is the size of the printer's image data buffer
+const printerBufferSize int = 0x2280
+
+// printerBusyPolls is the number of status requests that
+// return the printing flag after a print command
+const printerBusyPolls int = 4
+
+// printerShades of the 4 printed colors (white to black)
+var printerShades = [4]byte{0xFF, 0xAA, 0x55, 0x00}
+
+// Printer is a peer that emulates the Game Boy Printer, every
+// printed paper is saved to a timestamped PNG file
+type Printer struct {
+	dir         string
+	state       int
+	command     byte
+	compression byte
+	length      uint16
+	checksum    uint16
+	sum         uint16
+	packet      []byte
+	reply       byte
+	status      byte
+	busy        int
+	buffer      []byte // image data (2bpp tiles)
+	paper       []byte // printed pixels (PrinterWidth per line)
+	onPrint     func(file string, err error)
+}
+
+// NewPrinter creates Printer instance, the images are saved to 'dir'
+func NewPrinter(dir string) *Printer {
+	return &Printer{dir: dir}
+}
+
+// OnPrint sets the function that is called after a paper is saved
+func (p *Printer) OnPrint(fn func(file string, err error)) {
+	p.onPrint = fn
+}
+
+// Transfer receives 'out' and returns the printer's byte
+func (p *Printer) Transfer(out byte) byte {
+
+	// the reply is shifted out while 'out' is shifted in
+	reply := p.reply
+	p.reply = 0x00
+
+	switch p.state {
+
+	case stateMagic1:
+
+		if out == 0x88 {
+			p.state = stateMagic2
+		}
+
+	case stateMagic2:
+
+		if out == 0x33 {
+			p.state = stateCommand
+			p.sum = 0
+		} else if out != 0x88 {
+			p.state = stateMagic1
+		}
+
+	case stateCommand:
+
+		p.command = out
+		p.sum += uint16(out)
+		p.state = stateCompression
+
+	case stateCompression:
+
+		p.compression = out
+		p.sum += uint16(out)
+		p.state = stateLengthLow
+
+	case stateLengthLow:
+
+		p.length = uint16(out)
+		p.sum += uint16(out)
+		p.state = stateLengthHigh
+
+	case stateLengthHigh:
+
+		p.length |= uint16(out) << 8
+		p.sum += uint16(out)
+		p.packet = p.packet[:0]
+
+		if p.length > 0 {
+			p.state = stateData
+		} else {
+			p.state = stateChecksumLow
+		}
+
+	case stateData:
+
+		p.packet = append(p.packet, out)
+		p.sum += uint16(out)
+
+		if len(p.packet) >= int(p.length) {
+			p.state = stateChecksumLow
+		}
+
+	case stateChecksumLow:
+
+		p.checksum = uint16(out)
+		p.state = stateChecksumHigh
+
+	case stateChecksumHigh:
+
+		p.checksum |= uint16(out) << 8
+		p.execute()
+		p.reply = 0x81
+		p.state = stateAlive
+
+	case stateAlive:
+
+		p.reply = p.status
+		p.state = stateStatus
+
+	case stateStatus:
+
+		p.state = stateMagic1
+	}
+
+	return reply
+}
+
+// Poll always returns false (the printer never drives the clock)
+func (p *Printer) Poll(out byte) (byte, bool) {
+	return 0xFF, false
+}
+
+// execute the received packet
+func (p *Printer) execute() {
+
+	if p.checksum != p.sum {
+		p.status |= printerChecksumError
+		return
+	}
+
+	p.status &^= printerChecksumError
+
+	switch p.command {
+
+	case printerInit:
+
+		p.buffer = p.buffer[:0]
+		p.status = 0
+		p.busy = 0
+
+	case printerData:
+
+		data := p.packet
+
+		if p.compression != 0 {
+			data = decompress(data)
+		}
+
+		p.buffer = append(p.buffer, data...)
+
+		if len(p.buffer) > 0 {
+			p.status |= printerUnprocessed
+		}
+
+		if len(p.buffer) >= printerBufferSize {
+			p.status |= printerDataFull
+		}
+
+	case printerPrint:
+
+		if len(p.packet) < 4 {
+			return
+		}
+
+		p.print(p.packet[0], p.packet[1], p.packet[2])
+
+		p.buffer = p.buffer[:0]
+		p.status = printerPrinting | printerDataFull
+		p.busy = printerBusyPolls
+
+	case printerStatus:
+
+		if p.busy > 0 {
+
+			p.busy--
+
+			if p.busy == 0 {
+				p.status &^= printerPrinting | printerDataFull | printerUnprocessed
+			}
+		}
+	}
+}
+
+// decompress rle data, a control byte with bit 7 set is followed by a
+// byte that repeats (control & 0x7F) + 2 times, otherwise it is followed
+// by control + 1 literal bytes
+func decompress(data []byte) []byte {
+
+	var out []byte
+
+	for i := 0; i < len(data); {
+
+		control := data[i]
+		i++
+
+		if control&0x80 == 0x80 {
+
+			if i < len(data) {
+				for n := 0; n < int(control&0x7F)+2; n++ {
+					out = append(out, data[i])
+				}
+			}
+
+			i++
+
+		} else {
+
+			n := int(control) + 1
+
+			if i+n > len(data) {
+				n = len(data) - i
+			}
+
+			out = append(out, data[i:i+n]...)
+			i += n
+		}
+	}
+
+	return out
+}
+
+// print the buffered tiles with 'palette', the upper nibble of 'margins'
+// is the number of blank tile rows before the image, the lower nibble
+// is the number after the image (the paper is cut when it's not zero)
+func (p *Printer) print(sheets, margins, palette byte) {
+
+	// the default palette
+	if palette == 0 {
+		palette = 0xE4
+	}
+
+	p.feed(int(margins >> 4))
+
+	if sheets > 0 {
+		p.render(palette)
+	}
+
+	if after := int(margins & 0x0F); after > 0 {
+
+		p.feed(after)
+
+		file, err := p.save()
+
+		if p.onPrint != nil {
+			p.onPrint(file, err)
+		}
+	}
+}
+
+// feed 'rows' blank tile rows
+func (p *Printer) feed(rows int) {
+
+	for i := 0; i < rows*8*PrinterWidth; i++ {
+		p.paper = append(p.paper, printerShades[0])
+	}
+}
+
+// render the buffered 2bpp tiles (20 tiles per row) to the paper
+func (p *Printer) render(palette byte) {
+
+	const tilesPerRow = 20
+	const rowSize = tilesPerRow * 16
+
+	for row := 0; row+rowSize <= len(p.buffer); row += rowSize {
+
+		for y := 0; y < 8; y++ {
+
+			for tile := 0; tile < tilesPerRow; tile++ {
+
+				lo := p.buffer[row+tile*16+y*2]
+				hi := p.buffer[row+tile*16+y*2+1]
+
+				for x := uint(0); x < 8; x++ {
+
+					bit := 7 - x
+					color := ((hi>>bit)&1)<<1 | (lo>>bit)&1
+					shade := (palette >> (color * 2)) & 0x03
+
+					p.paper = append(p.paper, printerShades[shade])
+				}
+			}
+		}
+	}
+}
+
+// Flush saves the printed paper that wasn't cut yet
+func (p *Printer) Flush() error {
+
+	if len(p.paper) == 0 {
+		return nil
+	}
+
+	file, err := p.save()
+
+	if p.onPrint != nil {
+		p.onPrint(file, err)
+	}
+
+	return err
+}
+
+// save the paper to a timestamped png file and start a new paper
+func (p *Printer) save() (string, error) {
+
+	paper := p.paper
+	p.paper = nil
+
+	if len(paper) == 0 {
+		return "", nil
+	}
+
+	file := filepath.Join(p.dir, fmt.Sprintf("print-%s.png", time.Now().Format("20060102-150405.000")))
+
+	f, err := os.Create(file)
+
+	if err != nil {
+		return file, err
+	}
+
+	defer f.Close()
+
+	img := &image.Gray{
+		Pix:    paper,
+		Stride: PrinterWidth,
+		Rect:   image.Rect(0, 0, PrinterWidth, len(paper)/PrinterWidth)}
+
+	return file, png.Encode(f, img)
+}
@@ -0,0 +1,190 @@
+package serial
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TestDecompress checks the rle decompression of the printer data
+func TestDecompress(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"empty", nil, nil},
+		{"literal", []byte{0x02, 0x11, 0x22, 0x33}, []byte{0x11, 0x22, 0x33}},
+		{"repeat", []byte{0x81, 0x44}, []byte{0x44, 0x44, 0x44}},
+		{"mixed", []byte{0x00, 0x11, 0x80, 0x22, 0x01, 0x33, 0x44}, []byte{0x11, 0x22, 0x22, 0x33, 0x44}},
+		{"short literal", []byte{0x03, 0x11, 0x22}, []byte{0x11, 0x22}},
+		{"short repeat", []byte{0x00, 0x11, 0x85}, []byte{0x11}},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			if got := decompress(test.data); !bytes.Equal(got, test.want) {
+				t.Errorf("got % x, want % x", got, test.want)
+			}
+		})
+	}
+}
+
+// printerPacket returns the bytes of a printer packet, 'corrupt' is
+// added to the checksum, the packet ends with the alive and status bytes
+func printerPacket(command, compression byte, data []byte, corrupt uint16) []byte {
+
+	packet := []byte{0x88, 0x33, command, compression, byte(len(data)), byte(len(data) >> 8)}
+	packet = append(packet, data...)
+
+	var sum uint16
+
+	for _, b := range packet[2:] {
+		sum += uint16(b)
+	}
+
+	sum += corrupt
+
+	return append(packet, byte(sum), byte(sum>>8), 0x00, 0x00)
+}
+
+// sendPacket transfers 'packet' to the printer and checks that only the
+// alive (0x81) and status bytes are answered, returns the status
+func sendPacket(t *testing.T, p *Printer, packet []byte) byte {
+
+	var reply byte
+
+	for i, b := range packet {
+
+		reply = p.Transfer(b)
+
+		switch i {
+		case len(packet) - 2:
+			if reply != 0x81 {
+				t.Fatalf("alive byte = %02x, want 81", reply)
+			}
+		case len(packet) - 1:
+		default:
+			if reply != 0x00 {
+				t.Fatalf("byte %d = %02x, want 00", i, reply)
+			}
+		}
+	}
+
+	return reply
+}
+
+// TestPrinterPackets sends a sequence of packets and checks the status
+// that is returned after each one
+func TestPrinterPackets(t *testing.T) {
+
+	tiles := make([]byte, 40*16)
+
+	tests := []struct {
+		name   string
+		packet []byte
+		status byte
+	}{
+		{"init", printerPacket(printerInit, 0, nil, 0), 0x00},
+		{"bad checksum", printerPacket(printerData, 0, tiles, 1), printerChecksumError},
+		{"data", printerPacket(printerData, 0, tiles, 0), printerUnprocessed},
+		{"compressed data", printerPacket(printerData, 1, []byte{0xFF, 0x00}, 0), printerUnprocessed},
+		{"status", printerPacket(printerStatus, 0, nil, 0), printerUnprocessed},
+		{"print", printerPacket(printerPrint, 0, []byte{0x01, 0x00, 0xE4, 0x40}, 0), printerPrinting | printerDataFull},
+		{"busy", printerPacket(printerStatus, 0, nil, 0), printerPrinting | printerDataFull},
+	}
+
+	p := NewPrinter(".")
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			if status := sendPacket(t, p, test.packet); status != test.status {
+				t.Errorf("status = %02x, want %02x", status, test.status)
+			}
+		})
+	}
+
+	for i := 1; i < printerBusyPolls; i++ {
+		sendPacket(t, p, printerPacket(printerStatus, 0, nil, 0))
+	}
+
+	if status := sendPacket(t, p, printerPacket(printerStatus, 0, nil, 0)); status != 0x00 {
+		t.Errorf("status after printing = %02x, want 00", status)
+	}
+}
+
+// TestPrinterPaper prints two tile rows with a margin after
+// them and checks the saved image
+func TestPrinterPaper(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "gopherboy-printer")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var file string
+
+	p := NewPrinter(dir)
+
+	p.OnPrint(func(f string, err error) {
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file = f
+	})
+
+	// the first tile row is black (color 3), the second is white
+	tiles := make([]byte, 40*16)
+
+	for i := 0; i < 20*16; i++ {
+		tiles[i] = 0xFF
+	}
+
+	sendPacket(t, p, printerPacket(printerInit, 0, nil, 0))
+	sendPacket(t, p, printerPacket(printerData, 0, tiles, 0))
+	sendPacket(t, p, printerPacket(printerPrint, 0, []byte{0x01, 0x01, 0xE4, 0x40}, 0))
+
+	if len(file) == 0 {
+		t.Fatal("the paper wasn't saved")
+	}
+
+	f, err := os.Open(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	img, err := png.Decode(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 tile rows and a blank tile row
+	if b := img.Bounds(); b.Dx() != PrinterWidth || b.Dy() != 24 {
+		t.Fatalf("image size = %dx%d, want %dx24", b.Dx(), b.Dy(), PrinterWidth)
+	}
+
+	for _, pixel := range []struct{ x, y, shade int }{{0, 0, 3}, {159, 7, 3}, {0, 8, 0}, {80, 23, 0}} {
+
+		r, _, _, _ := img.At(pixel.x, pixel.y).RGBA()
+
+		if want := uint32(printerShades[pixel.shade]) * 0x101; r != want {
+			t.Errorf("pixel (%d, %d) = %04x, want %04x", pixel.x, pixel.y, r, want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package serial
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/cpu"
+	"github.com/moshenahmias/gopherboy/memory"
+)
+
+// AddrSB is the serial transfer data register address
+const AddrSB uint16 = 0xFF01
+
+// AddrSC is the serial transfer control register address
+const AddrSC uint16 = 0xFF02
+
+// BitRate in cycles (8192 Hz internal clock)
+const BitRate int = 512
+
+// FastBitRate in cycles (262144 Hz internal clock, cgb only)
+const FastBitRate int = 16
+
+// Port emulates the gameboy's serial link port
+type Port struct {
+	sb      byte
+	sc      byte
+	counter int
+	peer    Peer
+	ex      Exchanger // the peer, if it answers asynchronously
+	sent    bool      // the byte was sent to the exchanger
+	core    *cpu.Core
+	cgb     bool
+}
+
+// portState is the serialized form of the serial port
+type portState struct {
+	SB      byte
+	SC      byte
+	Counter int32
+}
+
+// NewPort creates Port instance, the port is disconnected,
+// in cgb mode the clock speed (SC bit 1) can be selected
+func NewPort(core *cpu.Core, cgb bool) *Port {
+
+	p := Port{core: core, peer: Disconnected{}, cgb: cgb}
+	core.RegisterToClockChanges(&p)
+	return &p
+}
+
+// Connect the port to 'peer'
+func (p *Port) Connect(peer Peer) {
+
+	p.peer = peer
+	p.ex, _ = peer.(Exchanger)
+	p.sent = false
+
+	if p.ex != nil {
+		p.core.SetSyncHook(p.ex.Sync)
+	} else {
+		p.core.SetSyncHook(nil)
+	}
+}
+
+// transferring returns true while a transfer is in progress
+func (p *Port) transferring() bool {
+	return p.sc&0x80 == 0x80
+}
+
+// internalClock returns true if this side drives the clock
+func (p *Port) internalClock() bool {
+	return p.sc&0x01 == 0x01
+}
+
+// bitRate returns the internal clock period of a single bit in cycles
+func (p *Port) bitRate() int {
+
+	if p.sc&0x02 == 0x02 {
+		return FastBitRate
+	}
+
+	return BitRate
+}
+
+// Read from the serial registers
+func (p *Port) Read(addr uint16) (byte, error) {
+
+	if addr == AddrSB {
+		return p.sb, nil
+	}
+
+	if addr == AddrSC {
+
+		if p.cgb {
+			return p.sc | 0x7C, nil
+		}
+
+		return p.sc | 0x7E, nil
+	}
+
+	return 0, memory.ReadOutOfRangeError(addr)
+}
+
+// Write to the serial registers
+func (p *Port) Write(addr uint16, data byte) error {
+
+	if addr == AddrSB {
+		p.sb = data
+		return nil
+	}
+
+	if addr == AddrSC {
+
+		if p.cgb {
+			p.sc = data & 0x83
+		} else {
+			p.sc = data & 0x81
+		}
+
+		p.counter = 0
+		p.sent = false
+		return nil
+	}
+
+	return memory.WriteOutOfRangeError(addr)
+}
+
+// ClockChanged is called after every instruction execution
+func (p *Port) ClockChanged(cycles int) error {
+
+	if p.ex != nil {
+		p.ex.Clock(cycles)
+	}
+
+	if !p.transferring() {
+		return nil
+	}
+
+	if p.internalClock() {
+
+		p.counter += cycles
+
+		if p.ex != nil {
+			p.exchange()
+			return nil
+		}
+
+		// the byte is shifted out (and in) after 8 bits
+		if p.counter >= 8*p.bitRate() {
+			p.complete(p.peer.Transfer(p.sb))
+		}
+
+		return nil
+	}
+
+	// external clock, wait for the peer
+	if in, ok := p.peer.Poll(p.sb); ok {
+		p.complete(in)
+	}
+
+	return nil
+}
+
+// exchange sends the byte as soon as the transfer starts, the transfer
+// completes after 8 bits once the answer arrived, or with 0xFF when
+// the answer didn't arrive within the exchanger's cycle budget
+func (p *Port) exchange() {
+
+	if !p.sent {
+		p.ex.Send(p.sb)
+		p.sent = true
+	}
+
+	if p.counter < 8*p.bitRate() {
+		return
+	}
+
+	if in, ok := p.ex.Answer(); ok {
+		p.complete(in)
+		return
+	}
+
+	if p.counter >= 8*p.bitRate()+p.ex.Budget() {
+		p.ex.Cancel()
+		p.complete(0xFF)
+	}
+}
+
+// complete a transfer with the received byte 'in'
+func (p *Port) complete(in byte) {
+
+	p.sb = in
+	p.sc &= 0x7F
+	p.counter = 0
+	p.sent = false
+
+	// request serial interrupt
+	p.core.RequestInterrupt(cpu.SerialLinkFlag)
+}
+
+// SaveState writes the serial registers and counter to 'w'
+func (p *Port) SaveState(w io.Writer) error {
+
+	s := portState{
+		SB:      p.sb,
+		SC:      p.sc,
+		Counter: int32(p.counter)}
+
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// LoadState reads the serial registers and counter from 'r'
+func (p *Port) LoadState(r io.Reader) error {
+
+	var s portState
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	p.sb = s.SB
+	p.sc = s.SC
+	p.counter = int(s.Counter)
+	p.sent = false
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package sgb
+
+import (
+	"github.com/moshenahmias/gopherboy/display"
+)
+
+// transferSize is the size of a vram transfer (256 tiles)
+const transferSize int = 4096
+
+// Monitor returns a monitor that colors the frames and draws
+// them (and the border) on 'm'
+func (s *SGB) Monitor(m display.Monitor) display.Monitor {
+	return &monitor{Monitor: m, s: s}
+}
+
+// monitor colors the frames before drawing them
+type monitor struct {
+	display.Monitor
+	s    *SGB
+	last display.Frame // the last drawn frame (for the freeze mask)
+}
+
+// DrawFrame reads a pending vram transfer from the frame,
+// draws the border (if changed) and the colored frame
+func (m *monitor) DrawFrame(f *display.Frame) error {
+
+	s := m.s
+
+	if s.transfer != transferNone {
+		s.vramTransfer(f)
+	}
+
+	if s.redraw {
+
+		s.redraw = false
+
+		if d, ok := m.Monitor.(display.BorderDrawer); ok {
+			if err := d.DrawBorder(s.border()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.mask != maskFreeze {
+		s.colorize(f, &m.last)
+	}
+
+	return m.Monitor.DrawFrame(&m.last)
+}
+
+// vramTransfer reads the data of the pending transfer command from the
+// frame, the game displays the data as the first 256 tiles of the screen
+func (s *SGB) vramTransfer(f *display.Frame) {
+
+	data := make([]byte, transferSize)
+
+	for t := 0; t < 256; t++ {
+
+		tx, ty := (t%attrWidth)*8, (t/attrWidth)*8
+
+		for row := 0; row < 8; row++ {
+
+			var lo, hi byte
+
+			for col := 0; col < 8; col++ {
+				shade := byte(f[tx+col][ty+row]) & 0x03
+				lo |= (shade & 0x01) << uint(7-col)
+				hi |= (shade >> 1) << uint(7-col)
+			}
+
+			data[t*16+row*2] = lo
+			data[t*16+row*2+1] = hi
+		}
+	}
+
+	switch s.transfer {
+
+	case cmdPALTRN:
+
+		for i := range s.sysPalettes {
+			for c := range s.sysPalettes[i] {
+				s.sysPalettes[i][c] = color(data, (i*4+c)*2)
+			}
+		}
+
+	case cmdCHRTRN:
+
+		copy(s.tiles[int(s.transferArg&0x01)*transferSize:], data)
+		s.redraw = s.bordered
+
+	case cmdPCTTRN:
+
+		for i := range s.tileMap {
+			s.tileMap[i] = uint16(data[i*2]) | uint16(data[i*2+1])<<8
+		}
+
+		for i := range s.borderPalettes {
+			for c := range s.borderPalettes[i] {
+				s.borderPalettes[i][c] = color(data, 0x800+(i*16+c)*2)
+			}
+		}
+
+		s.bordered = true
+		s.redraw = true
+
+	case cmdATTRTRN:
+
+		for i := range s.atfs {
+			copy(s.atfs[i][:], data[i*atfSize:])
+		}
+	}
+
+	s.transfer = transferNone
+}
+
+// colorize colors the shades of frame 'f' by the palette of each 8x8 cell
+func (s *SGB) colorize(f *display.Frame, out *display.Frame) {
+
+	for x := 0; x < display.ScreenWidth; x++ {
+		for y := 0; y < display.ScreenHeight; y++ {
+
+			shade := f[x][y] & 0x03
+			c := s.palettes[0][0]
+
+			switch {
+			case s.mask == maskBlack:
+				c = 0x0000
+			case s.mask == maskColor0, shade == 0:
+			default:
+				c = s.palettes[s.attrs[(y/8)*attrWidth+x/8]][shade]
+			}
+
+			out[x][y] = display.RGB555(c)
+		}
+	}
+}
+
+// border draws the border tiles, color 0 of the tiles
+// is the shared color 0 of the palettes
+func (s *SGB) border() *display.Border {
+
+	var b display.Border
+
+	backdrop := display.RGB555(s.palettes[0][0])
+
+	for ty := 0; ty < 28; ty++ {
+		for tx := 0; tx < 32; tx++ {
+
+			entry := s.tileMap[ty*32+tx]
+			tile := s.tiles[int(entry&0xFF)*32:]
+			palette := (entry >> 10) & 0x03 // palettes 4 - 7
+
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+
+					px, py := x, y
+
+					if entry&0x4000 == 0x4000 {
+						px = 7 - x
+					}
+
+					if entry&0x8000 == 0x8000 {
+						py = 7 - y
+					}
+
+					bit := uint(7 - px)
+
+					c := (tile[py*2]>>bit)&0x01 |
+						((tile[py*2+1]>>bit)&0x01)<<1 |
+						((tile[16+py*2]>>bit)&0x01)<<2 |
+						((tile[16+py*2+1]>>bit)&0x01)<<3
+
+					p := backdrop
+
+					if c != 0 {
+						p = display.RGB555(s.borderPalettes[palette][c])
+					}
+
+					b[tx*8+x][ty*8+y] = p
+				}
+			}
+		}
+	}
+
+	return &b
+}
@@ -0,0 +1,472 @@
+// Package sgb emulates the super game boy functions of sgb enhanced
+// games, the command packets, color palettes, borders and multiplayer
+package sgb
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/moshenahmias/gopherboy/joypad"
+)
+
+// the supported commands (the other commands are ignored)
+const (
+	cmdPAL01   byte = 0x00
+	cmdPAL23   byte = 0x01
+	cmdPAL03   byte = 0x02
+	cmdPAL12   byte = 0x03
+	cmdATTRBLK byte = 0x04
+	cmdATTRLIN byte = 0x05
+	cmdATTRDIV byte = 0x06
+	cmdATTRCHR byte = 0x07
+	cmdPALSET  byte = 0x0A
+	cmdPALTRN  byte = 0x0B
+	cmdMLTREQ  byte = 0x11
+	cmdCHRTRN  byte = 0x13
+	cmdPCTTRN  byte = 0x14
+	cmdATTRTRN byte = 0x15
+	cmdATTRSET byte = 0x16
+	cmdMASKEN  byte = 0x17
+)
+
+// transferNone marks that no vram transfer is pending
+const transferNone byte = 0xFF
+
+// the screen masks (MASK_EN)
+const (
+	maskNone   byte = 0
+	maskFreeze byte = 1
+	maskBlack  byte = 2
+	maskColor0 byte = 3
+)
+
+// attrWidth is the width of the attribute map (in 8x8 cells)
+const attrWidth int = 20
+
+// attrHeight is the height of the attribute map (in 8x8 cells)
+const attrHeight int = 18
+
+// atfCount is the number of attribute files (ATTR_TRN)
+const atfCount int = 45
+
+// atfSize is the size of a single attribute file (2 bits per cell)
+const atfSize int = attrWidth * attrHeight / 4
+
+// SGB is the super game boy, it reads the command packets that the
+// game sends through the joypad register and colors the frames
+type SGB struct {
+	joyp *joypad.JOYP
+
+	lines     byte     // the last P14 / P15 lines
+	receiving bool     // a packet is being received
+	bit       int      // the next bit of the packet
+	packet    [16]byte // the packet being received
+	data      []byte   // the received packets of the current command
+
+	palettes    [4][4]uint16   // the active palettes (RGB555)
+	sysPalettes [512][4]uint16 // the palettes of PAL_TRN
+	attrs       [attrWidth * attrHeight]byte
+	atfs        [atfCount][atfSize]byte
+	mask        byte
+	transfer    byte // the pending vram transfer command
+	transferArg byte
+
+	tiles          [256 * 32]byte // border tiles (4 bits per pixel)
+	tileMap        [32 * 28]uint16
+	borderPalettes [4][16]uint16
+	bordered       bool // a border was transferred
+	redraw         bool // the border needs to be drawn again
+}
+
+// sgbState is the serialized form of the super game boy
+type sgbState struct {
+	Palettes       [4][4]uint16
+	SysPalettes    [512][4]uint16
+	Attrs          [attrWidth * attrHeight]byte
+	ATFs           [atfCount][atfSize]byte
+	Mask           byte
+	Transfer       byte
+	TransferArg    byte
+	Tiles          [256 * 32]byte
+	TileMap        [32 * 28]uint16
+	BorderPalettes [4][16]uint16
+	Bordered       bool
+}
+
+// NewSGB creates SGB instance, the packets are read from 'joyp'
+func NewSGB(joyp *joypad.JOYP) *SGB {
+
+	s := SGB{joyp: joyp, lines: 0x30, transfer: transferNone}
+
+	// the default palettes are the super game boy's 1-A palette
+	for i := range s.palettes {
+		s.palettes[i] = [4]uint16{0x639E, 0x263A, 0x10D4, 0x2866}
+	}
+
+	joyp.SetLinesListener(&s)
+
+	return &s
+}
+
+// LinesWritten receives the packets bit by bit, a packet starts with a
+// reset pulse (both lines low), every bit is a pulse on a single line
+// (P14 for 0 and P15 for 1), 128 bits (lsb first) and a stop bit
+func (s *SGB) LinesWritten(lines byte) {
+
+	if lines == s.lines {
+		return
+	}
+
+	s.lines = lines
+
+	switch lines {
+
+	case 0x00:
+
+		// reset pulse
+		s.receiving = true
+		s.bit = 0
+		s.packet = [16]byte{}
+
+	case 0x30:
+
+		// between the pulses
+
+	default:
+
+		if !s.receiving {
+			return
+		}
+
+		// stop bit
+		if s.bit == 128 {
+			s.receiving = false
+			s.packetReceived()
+			return
+		}
+
+		if lines == 0x10 {
+			s.packet[s.bit/8] |= 1 << uint(s.bit%8)
+		}
+
+		s.bit++
+	}
+}
+
+// packetReceived collects the packets of a command, the first byte
+// of the first packet holds the command (bits 3 - 7) and the
+// number of packets (bits 0 - 2)
+func (s *SGB) packetReceived() {
+
+	if len(s.data) == 0 && s.packet[0]&0x07 == 0 {
+		return
+	}
+
+	s.data = append(s.data, s.packet[:]...)
+
+	if len(s.data) < int(s.data[0]&0x07)*16 {
+		return
+	}
+
+	data := s.data
+	s.data = nil
+
+	s.command(data[0]>>3, data)
+}
+
+// command executes a command, 'data' holds all of its packets
+func (s *SGB) command(cmd byte, data []byte) {
+
+	switch cmd {
+
+	case cmdPAL01:
+		s.setPalettes(0, 1, data)
+
+	case cmdPAL23:
+		s.setPalettes(2, 3, data)
+
+	case cmdPAL03:
+		s.setPalettes(0, 3, data)
+
+	case cmdPAL12:
+		s.setPalettes(1, 2, data)
+
+	case cmdATTRBLK:
+		s.attrBlocks(data)
+
+	case cmdATTRLIN:
+		s.attrLines(data)
+
+	case cmdATTRDIV:
+		s.attrDivide(data)
+
+	case cmdATTRCHR:
+		s.attrChars(data)
+
+	case cmdPALSET:
+		s.paletteSet(data)
+
+	case cmdPALTRN, cmdCHRTRN, cmdPCTTRN, cmdATTRTRN:
+
+		// the data is read from the next frame
+		s.transfer = cmd
+		s.transferArg = data[1]
+
+	case cmdATTRSET:
+		s.attrSet(data[1])
+
+	case cmdMASKEN:
+		s.mask = data[1] & 0x03
+
+	case cmdMLTREQ:
+
+		players := 1
+
+		switch data[1] & 0x03 {
+		case 0x01:
+			players = 2
+		case 0x03:
+			players = 4
+		}
+
+		s.joyp.SetPlayers(players)
+	}
+}
+
+// color reads an RGB555 color from data[i:]
+func color(data []byte, i int) uint16 {
+	return (uint16(data[i]) | uint16(data[i+1])<<8) & 0x7FFF
+}
+
+// setPalettes sets palettes 'a' and 'b' (PAL01, PAL23, PAL03 and
+// PAL12), color 0 is shared by all the palettes
+func (s *SGB) setPalettes(a, b int, data []byte) {
+
+	c0 := color(data, 1)
+
+	for i := range s.palettes {
+		s.palettes[i][0] = c0
+	}
+
+	for i := 0; i < 3; i++ {
+		s.palettes[a][i+1] = color(data, 3+i*2)
+		s.palettes[b][i+1] = color(data, 9+i*2)
+	}
+
+	s.redraw = s.bordered
+}
+
+// paletteSet sets the palettes from the system palettes (PAL_SET),
+// optionally applies an attribute file and cancels the mask
+func (s *SGB) paletteSet(data []byte) {
+
+	for i := range s.palettes {
+		s.palettes[i] = s.sysPalettes[(int(data[1+i*2])|int(data[2+i*2])<<8)&0x01FF]
+	}
+
+	if data[9]&0x80 == 0x80 {
+		s.attrSet(data[9])
+	} else if data[9]&0x40 == 0x40 {
+		s.mask = maskNone
+	}
+
+	s.redraw = s.bordered
+}
+
+// setAttr sets the palette of cell (x, y)
+func (s *SGB) setAttr(x, y int, palette byte) {
+
+	if x < attrWidth && y < attrHeight {
+		s.attrs[y*attrWidth+x] = palette & 0x03
+	}
+}
+
+// attrBlocks sets the palettes inside, on the border and outside of
+// rectangles (ATTR_BLK)
+func (s *SGB) attrBlocks(data []byte) {
+
+	for i := 0; i < int(data[1]) && 8+i*6 <= len(data); i++ {
+
+		d := data[2+i*6:]
+
+		ctrl := d[0] & 0x07
+		in := d[1] & 0x03
+		on := (d[1] >> 2) & 0x03
+		out := (d[1] >> 4) & 0x03
+
+		x1, y1 := int(d[2]&0x1F), int(d[3]&0x1F)
+		x2, y2 := int(d[4]&0x1F), int(d[5]&0x1F)
+
+		// changing only the inside (or the outside) changes the border too
+		switch ctrl {
+		case 0x01:
+			ctrl |= 0x02
+			on = in
+		case 0x04:
+			ctrl |= 0x02
+			on = out
+		}
+
+		for y := 0; y < attrHeight; y++ {
+			for x := 0; x < attrWidth; x++ {
+
+				switch {
+				case x1 < x && x < x2 && y1 < y && y < y2:
+					if ctrl&0x01 == 0x01 {
+						s.setAttr(x, y, in)
+					}
+				case x1 <= x && x <= x2 && y1 <= y && y <= y2:
+					if ctrl&0x02 == 0x02 {
+						s.setAttr(x, y, on)
+					}
+				default:
+					if ctrl&0x04 == 0x04 {
+						s.setAttr(x, y, out)
+					}
+				}
+			}
+		}
+	}
+}
+
+// attrLines sets the palettes of whole rows and columns (ATTR_LIN)
+func (s *SGB) attrLines(data []byte) {
+
+	for i := 0; i < int(data[1]) && 2+i < len(data); i++ {
+
+		d := data[2+i]
+		line := int(d & 0x1F)
+		palette := (d >> 5) & 0x03
+
+		if d&0x80 == 0x80 {
+
+			// horizontal line
+			for x := 0; x < attrWidth; x++ {
+				s.setAttr(x, line, palette)
+			}
+
+		} else {
+
+			// vertical line
+			for y := 0; y < attrHeight; y++ {
+				s.setAttr(line, y, palette)
+			}
+		}
+	}
+}
+
+// attrDivide divides the screen in two at a row or a column (ATTR_DIV)
+func (s *SGB) attrDivide(data []byte) {
+
+	after := data[1] & 0x03
+	before := (data[1] >> 2) & 0x03
+	on := (data[1] >> 4) & 0x03
+	line := int(data[2] & 0x1F)
+
+	for y := 0; y < attrHeight; y++ {
+		for x := 0; x < attrWidth; x++ {
+
+			pos := x
+
+			if data[1]&0x40 == 0x40 {
+				pos = y
+			}
+
+			switch {
+			case pos < line:
+				s.setAttr(x, y, before)
+			case pos == line:
+				s.setAttr(x, y, on)
+			default:
+				s.setAttr(x, y, after)
+			}
+		}
+	}
+}
+
+// attrChars sets the palettes of a run of cells (ATTR_CHR)
+func (s *SGB) attrChars(data []byte) {
+
+	x, y := int(data[1]&0x1F), int(data[2]&0x1F)
+	n := int(data[3]) | int(data[4])<<8
+	vertical := data[5]&0x01 == 0x01
+
+	for i := 0; i < n && 6+i/4 < len(data); i++ {
+
+		s.setAttr(x, y, data[6+i/4]>>uint(6-(i%4)*2))
+
+		if vertical {
+			if y++; y >= attrHeight {
+				y = 0
+				x++
+			}
+		} else {
+			if x++; x >= attrWidth {
+				x = 0
+				y++
+			}
+		}
+	}
+}
+
+// attrSet applies attribute file number bits 0 - 5 and
+// cancels the mask if bit 6 is set (ATTR_SET)
+func (s *SGB) attrSet(data byte) {
+
+	if n := int(data & 0x3F); n < atfCount {
+		for i := range s.attrs {
+			s.attrs[i] = (s.atfs[n][i/4] >> uint(6-(i%4)*2)) & 0x03
+		}
+	}
+
+	if data&0x40 == 0x40 {
+		s.mask = maskNone
+	}
+}
+
+// SaveState writes the palettes, attributes and border to 'w'
+func (s *SGB) SaveState(w io.Writer) error {
+
+	state := sgbState{
+		Palettes:       s.palettes,
+		SysPalettes:    s.sysPalettes,
+		Attrs:          s.attrs,
+		ATFs:           s.atfs,
+		Mask:           s.mask,
+		Transfer:       s.transfer,
+		TransferArg:    s.transferArg,
+		Tiles:          s.tiles,
+		TileMap:        s.tileMap,
+		BorderPalettes: s.borderPalettes,
+		Bordered:       s.bordered}
+
+	return binary.Write(w, binary.LittleEndian, &state)
+}
+
+// LoadState reads the palettes, attributes and border from 'r'
+func (s *SGB) LoadState(r io.Reader) error {
+
+	var state sgbState
+
+	if err := binary.Read(r, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	s.palettes = state.Palettes
+	s.sysPalettes = state.SysPalettes
+	s.attrs = state.Attrs
+	s.atfs = state.ATFs
+	s.mask = state.Mask & 0x03
+	s.transfer = state.Transfer
+	s.transferArg = state.TransferArg
+	s.tiles = state.Tiles
+	s.tileMap = state.TileMap
+	s.borderPalettes = state.BorderPalettes
+	s.bordered = state.Bordered
+	s.redraw = state.Bordered
+
+	s.receiving = false
+	s.data = nil
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package sgb
+
+import "testing"
+
+// sendPacket writes a packet to the lines, bit by bit, as the
+// game does through the joypad register
+func sendPacket(s *SGB, packet [16]byte) {
+
+	// reset pulse
+	s.LinesWritten(0x00)
+	s.LinesWritten(0x30)
+
+	for i := 0; i < 128; i++ {
+
+		if packet[i/8]&(1<<uint(i%8)) != 0 {
+			s.LinesWritten(0x10)
+		} else {
+			s.LinesWritten(0x20)
+		}
+
+		s.LinesWritten(0x30)
+	}
+
+	// stop bit
+	s.LinesWritten(0x20)
+	s.LinesWritten(0x30)
+}
+
+// TestAttributes sends the attribute commands and checks the
+// palettes of a few cells
+func TestAttributes(t *testing.T) {
+
+	type cell struct {
+		x, y    int
+		palette byte
+	}
+
+	tests := []struct {
+		name   string
+		packet [16]byte
+		cells  []cell
+	}{
+		{
+			"ATTR_BLK inside, border and outside",
+			[16]byte{cmdATTRBLK<<3 | 1, 1, 0x07, 0x39, 2, 3, 6, 8},
+			[]cell{{4, 5, 1}, {2, 5, 2}, {6, 8, 2}, {4, 3, 2}, {0, 0, 3}, {7, 5, 3}, {19, 17, 3}},
+		},
+		{
+			"ATTR_BLK inside only",
+			[16]byte{cmdATTRBLK<<3 | 1, 1, 0x01, 0x01, 2, 3, 6, 8},
+			[]cell{{4, 5, 1}, {2, 5, 1}, {6, 8, 1}, {0, 0, 0}, {7, 5, 0}},
+		},
+		{
+			"ATTR_BLK outside only",
+			[16]byte{cmdATTRBLK<<3 | 1, 1, 0x04, 0x20, 2, 3, 6, 8},
+			[]cell{{4, 5, 0}, {2, 5, 2}, {6, 8, 2}, {0, 0, 2}, {7, 5, 2}},
+		},
+		{
+			"ATTR_BLK two data sets",
+			[16]byte{cmdATTRBLK<<3 | 1, 2, 0x01, 0x01, 0, 0, 1, 1, 0x01, 0x03, 10, 10, 11, 11},
+			[]cell{{0, 0, 1}, {1, 1, 1}, {10, 10, 3}, {11, 11, 3}, {5, 5, 0}},
+		},
+		{
+			"ATTR_CHR horizontal wrap",
+			[16]byte{cmdATTRCHR<<3 | 1, 18, 0, 4, 0, 0, 0x6D},
+			[]cell{{18, 0, 1}, {19, 0, 2}, {0, 1, 3}, {1, 1, 1}, {2, 1, 0}},
+		},
+		{
+			"ATTR_CHR vertical wrap",
+			[16]byte{cmdATTRCHR<<3 | 1, 0, 16, 3, 0, 1, 0xE4},
+			[]cell{{0, 16, 3}, {0, 17, 2}, {1, 0, 1}, {1, 1, 0}},
+		},
+		{
+			"ATTR_LIN",
+			[16]byte{cmdATTRLIN<<3 | 1, 2, 0x80 | 0x20 | 4, 0x40 | 7},
+			[]cell{{0, 4, 1}, {19, 4, 1}, {7, 0, 2}, {7, 17, 2}, {0, 0, 0}},
+		},
+		{
+			"ATTR_DIV vertical",
+			[16]byte{cmdATTRDIV<<3 | 1, 0x40 | 0x30 | 0x04 | 0x02, 9},
+			[]cell{{0, 0, 1}, {19, 8, 1}, {5, 9, 3}, {0, 10, 2}, {19, 17, 2}},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+
+			s := &SGB{lines: 0x30, transfer: transferNone}
+
+			sendPacket(s, test.packet)
+
+			for _, c := range test.cells {
+				if palette := s.attrs[c.y*attrWidth+c.x]; palette != c.palette {
+					t.Errorf("cell (%d, %d) = %d, want %d", c.x, c.y, palette, c.palette)
+				}
+			}
+		})
+	}
+}
+
+// TestPalettes sends the palette commands, color 0 is shared by all
+// the palettes (the last one set wins)
+func TestPalettes(t *testing.T) {
+
+	s := &SGB{lines: 0x30, transfer: transferNone}
+
+	sendPacket(s, [16]byte{cmdPAL01<<3 | 1,
+		0x11, 0x00, 0x01, 0x01, 0x02, 0x01, 0x03, 0x01,
+		0x04, 0x02, 0x05, 0x02, 0xFF, 0xFF})
+
+	sendPacket(s, [16]byte{cmdPAL23<<3 | 1,
+		0x22, 0x00, 0x01, 0x03, 0x02, 0x03, 0x03, 0x03,
+		0x04, 0x04, 0x05, 0x04, 0x06, 0x04})
+
+	want := [4][4]uint16{
+		{0x0022, 0x0101, 0x0102, 0x0103},
+		{0x0022, 0x0204, 0x0205, 0x7FFF},
+		{0x0022, 0x0301, 0x0302, 0x0303},
+		{0x0022, 0x0404, 0x0405, 0x0406}}
+
+	if s.palettes != want {
+		t.Errorf("palettes = %04x, want %04x", s.palettes, want)
+	}
+
+	// a packet without a length is ignored
+	sendPacket(s, [16]byte{cmdPAL01 << 3, 0x33})
+
+	if s.palettes != want {
+		t.Errorf("palettes = %04x after an empty packet, want %04x", s.palettes, want)
+	}
+}
@@ -1,6 +1,9 @@
 package timers
 
 import (
+	"encoding/binary"
+	"io"
+
 	"github.com/moshenahmias/gopherboy/cpu"
 	"github.com/moshenahmias/gopherboy/memory"
 )
@@ -31,6 +34,16 @@ type Timer struct {
 	core        *cpu.Core
 }
 
+// timerState is the serialized form of the timer
+type timerState struct {
+	DIV         byte
+	TIMA        byte
+	TMA         byte
+	TAC         byte
+	DivCounter  int32
+	TimaCounter int32
+}
+
 // NewTimer creates GPU instance
 func NewTimer(core *cpu.Core) *Timer {
 
@@ -150,3 +163,36 @@ func (t *Timer) ClockChanged(cycles int) error {
 
 	return nil
 }
+
+// SaveState writes the timer registers and counters to 'w'
+func (t *Timer) SaveState(w io.Writer) error {
+
+	s := timerState{
+		DIV:         t.div,
+		TIMA:        t.tima,
+		TMA:         t.tma,
+		TAC:         t.tac,
+		DivCounter:  int32(t.divCounter),
+		TimaCounter: int32(t.timaCounter)}
+
+	return binary.Write(w, binary.LittleEndian, &s)
+}
+
+// LoadState reads the timer registers and counters from 'r'
+func (t *Timer) LoadState(r io.Reader) error {
+
+	var s timerState
+
+	if err := binary.Read(r, binary.LittleEndian, &s); err != nil {
+		return err
+	}
+
+	t.div = s.DIV
+	t.tima = s.TIMA
+	t.tma = s.TMA
+	t.tac = s.TAC
+	t.divCounter = int(s.DivCounter)
+	t.timaCounter = int(s.TimaCounter)
+
+	return nil
+}
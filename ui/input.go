@@ -32,18 +32,94 @@ const ControlEventPause ControlEvent = 2
 // ControlEventMute signals a mute request
 const ControlEventMute ControlEvent = 3
 
+// ControlEventSaveState signals a save state request (to the selected slot)
+const ControlEventSaveState ControlEvent = 4
+
+// ControlEventLoadState signals a load state request (from the selected slot)
+const ControlEventLoadState ControlEvent = 5
+
+// ControlEventSelectSlot signals that a different state slot was selected
+const ControlEventSelectSlot ControlEvent = 6
+
+// ControlEventTrace signals an execution trace toggle request
+const ControlEventTrace ControlEvent = 7
+
+// ControlEventFastForward signals that the fast forward key was pressed
+const ControlEventFastForward ControlEvent = 8
+
+// ControlEventFastForwardEnd signals that the fast forward key was released
+const ControlEventFastForwardEnd ControlEvent = 9
+
+// ControlEventSlowMotion signals a slow motion toggle request
+const ControlEventSlowMotion ControlEvent = 10
+
+// ControlEventFrameAdvance signals a request to run a single frame
+const ControlEventFrameAdvance ControlEvent = 11
+
+// ControlEventRewind signals that the rewind key was pressed
+const ControlEventRewind ControlEvent = 12
+
+// ControlEventRewindEnd signals that the rewind key was released
+const ControlEventRewindEnd ControlEvent = 13
+
+// ControlEventScreenshot signals a screenshot request
+const ControlEventScreenshot ControlEvent = 14
+
+// ControlEventRecord signals a recording toggle request
+const ControlEventRecord ControlEvent = 15
+
 // Input is a Keystroker implementer
 type Input struct {
 	keystrokes []joypad.Keystroke
 	m          sync.Mutex
 	mapping    map[int32]config.EJoypad
 	stop       bool
+	slot       int
+	players    []*Input // the additional players (sgb multiplayer)
 }
 
-// NewInput creates Input instance
-func NewInput(mapping map[int32]config.EJoypad) *Input {
+// NewInput creates Input instance, 'players' are the
+// key mappings of the additional players (sgb multiplayer)
+func NewInput(mapping map[int32]config.EJoypad, players ...map[int32]config.EJoypad) *Input {
+
+	i := &Input{mapping: mapping, stop: false}
+
+	for _, p := range players {
+		i.players = append(i.players, &Input{mapping: p})
+	}
+
+	return i
+}
 
-	return &Input{mapping: mapping, stop: false}
+// Players returns the number of players (including the first)
+func (i *Input) Players() int {
+	return len(i.players) + 1
+}
+
+// Player returns the keystroker of player 'n' (0 is the first player)
+func (i *Input) Player(n int) *Input {
+
+	if n == 0 {
+		return i
+	}
+
+	return i.players[n-1]
+}
+
+// mapped returns true if the key is mapped to the joypad of any player
+func (i *Input) mapped(code sdl.Keycode) bool {
+
+	if _, found := i.mapping[int32(code)]; found {
+		return true
+	}
+
+	for _, p := range i.players {
+		if p.mapped(code) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // convertJoypadCode to internal system code
@@ -104,6 +180,11 @@ func (i *Input) GetKeystroke() *joypad.Keystroke {
 	return &ks
 }
 
+// Slot returns the selected save state slot (0 - 9)
+func (i *Input) Slot() int {
+	return i.slot
+}
+
 // Stop waiting for key events
 func (i *Input) Stop() {
 	i.stop = true
@@ -127,13 +208,28 @@ func (i *Input) AddKeyEvent(code sdl.Keycode, pressed bool) {
 			i.m.Unlock()
 		}
 	}
+
+	for _, p := range i.players {
+		p.AddKeyEvent(code, pressed)
+	}
 }
 
-// WaitForKeyEvents blocks until a key is pressed or unpressed
-func (i *Input) WaitForKeyEvents() ControlEvent {
+// Reset drops the queued keystrokes of every player and cancels
+// a previous Stop (e.g. when a new game starts)
+func (i *Input) Reset() {
 
+	i.m.Lock()
 	i.keystrokes = nil
 	i.stop = false
+	i.m.Unlock()
+
+	for _, p := range i.players {
+		p.Reset()
+	}
+}
+
+// WaitForKeyEvents blocks until a key is pressed or unpressed
+func (i *Input) WaitForKeyEvents() ControlEvent {
 
 	for !i.stop {
 
@@ -171,10 +267,84 @@ func (i *Input) WaitForKeyEvents() ControlEvent {
 				return ControlEventMute
 			}
 
+			if t.Keysym.Sym == sdl.K_F5 {
+
+				return ControlEventSaveState
+			}
+
+			if t.Keysym.Sym == sdl.K_F8 {
+
+				return ControlEventLoadState
+			}
+
+			if t.Keysym.Sym == sdl.K_F9 {
+
+				return ControlEventTrace
+			}
+
+			if t.Keysym.Sym == sdl.K_F4 {
+
+				return ControlEventSlowMotion
+			}
+
+			if t.Keysym.Sym == sdl.K_F6 {
+
+				return ControlEventFrameAdvance
+			}
+
+			if t.Keysym.Sym == sdl.K_F12 {
+
+				return ControlEventScreenshot
+			}
+
+			if t.Keysym.Sym == sdl.K_F10 {
+
+				return ControlEventRecord
+			}
+
+			// fast forward while tab is held (unless mapped to the joypad)
+			if !i.mapped(t.Keysym.Sym) && t.Keysym.Sym == sdl.K_TAB {
+
+				if t.Repeat != 0 {
+					continue
+				}
+
+				return ControlEventFastForward
+			}
+
+			// rewind while backspace is held (unless mapped to the joypad)
+			if !i.mapped(t.Keysym.Sym) && t.Keysym.Sym == sdl.K_BACKSPACE {
+
+				if t.Repeat != 0 {
+					continue
+				}
+
+				return ControlEventRewind
+			}
+
+			// number keys select the state slot (unless mapped to the joypad)
+			if !i.mapped(t.Keysym.Sym) &&
+				sdl.K_0 <= t.Keysym.Sym && t.Keysym.Sym <= sdl.K_9 {
+
+				i.slot = int(t.Keysym.Sym - sdl.K_0)
+
+				return ControlEventSelectSlot
+			}
+
 			i.AddKeyEvent(t.Keysym.Sym, true)
 
 		case *sdl.KeyUpEvent:
 
+			if !i.mapped(t.Keysym.Sym) && t.Keysym.Sym == sdl.K_TAB {
+
+				return ControlEventFastForwardEnd
+			}
+
+			if !i.mapped(t.Keysym.Sym) && t.Keysym.Sym == sdl.K_BACKSPACE {
+
+				return ControlEventRewindEnd
+			}
+
 			i.AddKeyEvent(t.Keysym.Sym, false)
 		}
 	}
@@ -2,6 +2,11 @@ package ui
 
 import "github.com/veandco/go-sdl2/sdl"
 
+// maxQueuedBuffers is the number of queued buffers above which new
+// samples are dropped (e.g. while fast forwarding), only the device
+// drops samples, the recorders still get every sample
+const maxQueuedBuffers = 4
+
 // Sound plays samples from the apu
 type Sound struct {
 	dev         sdl.AudioDeviceID
@@ -16,6 +21,12 @@ func (s *Sound) Queue(samples []byte) error {
 		return nil
 	}
 
+	// drop the samples instead of piling up (the queue
+	// size is in bytes, the buffer size is in frames)
+	if s.SamplesCount()/2 > uint32(maxQueuedBuffers*int(s.BufferSize())) {
+		return nil
+	}
+
 	if err := sdl.QueueAudio(s.dev, samples); err != nil {
 		return err
 	}
@@ -44,8 +55,15 @@ func (s *Sound) Frequency() int {
 	return 44100
 }
 
-// SamplesCount return the current number of samples in buffer
+// SamplesCount return the current number of queued bytes (2 per
+// stereo frame), a full buffer is reported while there is no sound
+// so the scheduler doesn't try to catch up with an empty queue
 func (s *Sound) SamplesCount() uint32 {
+
+	if s.mute || !s.initialized {
+		return 2 * uint32(s.BufferSize())
+	}
+
 	return sdl.GetQueuedAudioSize(s.dev)
 }
 
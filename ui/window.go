@@ -15,6 +15,7 @@ type Window struct {
 	renderer *sdl.Renderer
 	texture  *sdl.Texture
 	colors   [4]uint32
+	border   *display.Border // the super game boy border (or nil)
 }
 
 // NewWindow creates Window instance
@@ -73,6 +74,32 @@ func (l *Window) Close() error {
 	return nil
 }
 
+// DrawBorder to window, the frames are drawn inside the border
+// (the window grows to the super game boy screen size)
+func (l *Window) DrawBorder(b *display.Border) error {
+
+	if l.border == nil {
+
+		width := display.BorderWidth * l.scale
+		height := display.BorderHeight * l.scale
+
+		texture, err := l.renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STREAMING, width, height)
+
+		if err != nil {
+			return err
+		}
+
+		l.texture.Destroy()
+		l.texture = texture
+		l.window.SetSize(width, height)
+	}
+
+	border := *b
+	l.border = &border
+
+	return nil
+}
+
 // DrawFrame to window
 func (l *Window) DrawFrame(f *display.Frame) error {
 
@@ -83,14 +110,23 @@ func (l *Window) DrawFrame(f *display.Frame) error {
 		return err
 	}
 
+	// frame position
+	fx, fy := 0, 0
+
+	if l.border != nil {
+
+		for j := 0; j < display.BorderHeight; j++ {
+			for i := 0; i < display.BorderWidth; i++ {
+				l.fill(pixels, pitch, i, j, l.color(l.border[i][j]))
+			}
+		}
+
+		fx, fy = display.BorderFrameX, display.BorderFrameY
+	}
+
 	for j := 0; j < display.ScreenHeight; j++ {
 		for i := 0; i < display.ScreenWidth; i++ {
-			for x := i * l.scale; x < i*l.scale+l.scale; x++ {
-				for y := j * l.scale; y < j*l.scale+l.scale; y++ {
-					p := unsafe.Pointer(uintptr(pixels) + unsafe.Sizeof(uint32(0))*uintptr(y*(pitch/4)+x))
-					*(*uint32)(p) = l.color((*f)[i][j])
-				}
-			}
+			l.fill(pixels, pitch, fx+i, fy+j, l.color((*f)[i][j]))
 		}
 	}
 
@@ -103,12 +139,18 @@ func (l *Window) DrawFrame(f *display.Frame) error {
 	return nil
 }
 
-// color converts color code to the actual color
-func (l *Window) color(code display.Pixel) uint32 {
+// fill the (scaled) pixel at (i, j) of the locked texture with color 'c'
+func (l *Window) fill(pixels unsafe.Pointer, pitch int, i, j int, c uint32) {
 
-	if code > 3 {
-		return 0xFFFFFF
+	for x := i * l.scale; x < i*l.scale+l.scale; x++ {
+		for y := j * l.scale; y < j*l.scale+l.scale; y++ {
+			p := unsafe.Pointer(uintptr(pixels) + unsafe.Sizeof(uint32(0))*uintptr(y*(pitch/4)+x))
+			*(*uint32)(p) = c
+		}
 	}
+}
 
-	return l.colors[code]
+// color converts a pixel to the actual color
+func (l *Window) color(p display.Pixel) uint32 {
+	return p.RGB(l.colors)
 }